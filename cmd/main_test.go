@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"testing"
 
 	"github.com/XiaoConstantine/mycli/pkg/commands/root"
@@ -15,7 +16,7 @@ func TestRun(t *testing.T) {
 	defer func() { update.CheckForUpdatesFunc = originalCheckForUpdates }()
 
 	// Mock the CheckForUpdates function
-	update.CheckForUpdatesFunc = func(iostream *iostreams.IOStreams) (bool, string, error) {
+	update.CheckForUpdatesFunc = func(ctx context.Context, iostream *iostreams.IOStreams) (bool, string, error) {
 		return false, "v1.0.0", nil // No update available
 	}
 	// Define test cases
@@ -0,0 +1,31 @@
+/*
+Package config provides commands that operate on the mycli config file itself
+rather than on the machine it describes.
+*/
+package config
+
+import (
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCmd creates and returns a cobra.Command for the 'config' command of mycli.
+//
+// Usage:
+//
+//	mycli config pin [name...] [flags]
+//	mycli config validate [flags]
+func NewConfigCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the mycli config file",
+		Annotations: map[string]string{
+			"group": "configure",
+		},
+	}
+
+	cmd.AddCommand(newPinCmd(iostream))
+	cmd.AddCommand(newValidateCmd(iostream))
+	return cmd
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigCmd(t *testing.T) {
+	stubDownload(t, map[string]string{
+		"https://example.com/uv.sh":    "echo uv",
+		"https://example.com/init.lua": "-- nvim",
+	})
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0644))
+
+	ios, _, stdout, _ := iostreams.Test()
+	cmd := NewConfigCmd(ios)
+	assert.Equal(t, "configure", cmd.Annotations["group"])
+	cmd.SetArgs([]string{"pin", "-c", path})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "script_sha256")
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/yaml.v3"
+)
+
+var download = utils.Download
+
+// pinnedURLs lists, per config section, the field holding a URL and the field
+// its SHA-256 is pinned in.
+var pinnedURLs = []struct {
+	section, urlKey, hashKey string
+}{
+	{"tools", "script_url", "script_sha256"},
+	{"configure", "config_url", "sha256"},
+	{"configure", "archive", "sha256"},
+}
+
+// newPinCmd creates the 'config pin' command, which downloads every URL the
+// config fetches and writes its current SHA-256 back into the config file.
+//
+// Usage:
+//
+//	mycli config pin [name...] [flags]
+//
+// Flags:
+//
+//	-c, --config string   Path to the configuration file (default "config.yaml")
+func newPinCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	var configFile string
+
+	cmd := &cobra.Command{
+		Use:   "pin [name...]",
+		Short: "Pin downloaded configs and install scripts to their current checksums",
+		Annotations: map[string]string{
+			"group": "configure",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "config_pin")
+			defer span.Finish()
+
+			stats, err := pinConfigFile(ctx, configFile, args)
+			if len(stats) > 0 {
+				utils.PrintCombinedStats(iostream, stats)
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	return cmd
+}
+
+// pinConfigFile pins the URLs in the config at path, restricted to the items
+// named in names when given. The file is edited as a YAML node tree so that
+// comments and key order survive.
+func pinConfigFile(ctx context.Context, path string, names []string) ([]*utils.Stats, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a mycli config", path)
+	}
+
+	var config utils.ToolConfig
+	if err := doc.Decode(&config); err == nil {
+		ctx = utils.WithAuth(ctx, config.Auth)
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var stats []*utils.Stats
+	var failed []string
+	changed := false
+	for _, p := range pinnedURLs {
+		items := mappingValue(doc.Content[0], p.section)
+		if items == nil || items.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range items.Content {
+			name := scalarValue(item, "name")
+			url := scalarValue(item, p.urlKey)
+			if url == "" || (len(wanted) > 0 && !wanted[name]) {
+				continue
+			}
+			delete(wanted, name)
+
+			start := time.Now()
+			stat := &utils.Stats{Name: name, Operation: "Pin"}
+			stats = append(stats, stat)
+			content, err := download(ctx, url)
+			stat.Duration = time.Since(start)
+			if err != nil {
+				stat.Status = "error"
+				stat.Detail = err.Error()
+				failed = append(failed, name)
+				continue
+			}
+
+			sum := utils.SHA256Hex(content)
+			stat.Detail = p.hashKey + " " + sum[:12]
+			if setScalar(item, p.urlKey, p.hashKey, sum) {
+				stat.Status = "success"
+				changed = true
+			} else {
+				stat.Status = "unchanged"
+			}
+		}
+	}
+
+	for name := range wanted {
+		failed = append(failed, name)
+		stats = append(stats, &utils.Stats{Name: name, Operation: "Pin", Status: "error", Detail: "no config_url, archive or script_url"})
+	}
+
+	if changed {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&doc); err != nil {
+			return stats, fmt.Errorf("failed to encode config: %v", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return stats, fmt.Errorf("failed to encode config: %v", err)
+		}
+		if err := utils.WriteFileAtomic(path, buf.Bytes(), info.Mode().Perm()); err != nil {
+			return stats, fmt.Errorf("failed to write config: %v", err)
+		}
+	}
+
+	if len(failed) > 0 {
+		return stats, fmt.Errorf("failed to pin %s", strings.Join(failed, ", "))
+	}
+	return stats, nil
+}
+
+// mappingValue returns the value node for key in mapping, or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarValue(mapping *yaml.Node, key string) string {
+	if node := mappingValue(mapping, key); node != nil && node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	return ""
+}
+
+// setScalar sets key to value in mapping, adding it right after the after key
+// when missing. It reports whether the mapping changed.
+func setScalar(mapping *yaml.Node, after, key, value string) bool {
+	if node := mappingValue(mapping, key); node != nil {
+		if node.Value == value {
+			return false
+		}
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!str", value
+		return true
+	}
+
+	pair := []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle},
+	}
+	at := len(mapping.Content)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == after {
+			at = i + 2
+			break
+		}
+	}
+	content := append([]*yaml.Node{}, mapping.Content[:at]...)
+	content = append(content, pair...)
+	mapping.Content = append(content, mapping.Content[at:]...)
+	return true
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `# My machine
+tools:
+  - name: "uv"
+    # Pinned below by mycli config pin
+    script_url: "https://example.com/uv.sh"
+  - name: "neovim"
+configure:
+  - name: "nvim"
+    config_url: "https://example.com/init.lua"
+    sha256: "stale"
+    install_path: "~/.config/nvim/init.lua"
+  - name: "zsh"
+    source_path: "~/dotfiles/zshrc"
+    install_path: "~/.zshrc"
+`
+
+func stubDownload(t *testing.T, content map[string]string) {
+	oldDownload := download
+	download = func(ctx context.Context, url string) ([]byte, error) {
+		c, ok := content[url]
+		if !ok {
+			return nil, fmt.Errorf("failed to download %s: HTTP status 404", url)
+		}
+		return []byte(c), nil
+	}
+	t.Cleanup(func() { download = oldDownload })
+}
+
+func TestPinConfigFile(t *testing.T) {
+	stubDownload(t, map[string]string{
+		"https://example.com/uv.sh":    "echo uv",
+		"https://example.com/init.lua": "-- nvim",
+	})
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0600))
+
+	stats, err := pinConfigFile(context.Background(), path, nil)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "uv", stats[0].Name)
+	assert.Equal(t, "success", stats[0].Status)
+	assert.Equal(t, "nvim", stats[1].Name)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# My machine")
+	assert.Contains(t, string(data), "# Pinned below by mycli config pin")
+	assert.Contains(t, string(data), "    script_url: \"https://example.com/uv.sh\"\n    script_sha256: \""+utils.SHA256Hex([]byte("echo uv"))+"\"\n")
+	assert.Contains(t, string(data), "sha256: \""+utils.SHA256Hex([]byte("-- nvim"))+"\"")
+	assert.NotContains(t, string(data), "stale")
+
+	config, err := utils.LoadToolsConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, utils.SHA256Hex([]byte("-- nvim")), config.Configure[0].SHA256)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Pinning again changes nothing.
+	stats, err = pinConfigFile(context.Background(), path, []string{"nvim"})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "unchanged", stats[0].Status)
+}
+
+func TestPinConfigFileErrors(t *testing.T) {
+	stubDownload(t, map[string]string{"https://example.com/init.lua": "-- nvim"})
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0644))
+
+	stats, err := pinConfigFile(context.Background(), path, []string{"uv", "nvim", "zsh"})
+	assert.EqualError(t, err, "failed to pin uv, zsh")
+	require.Len(t, stats, 3)
+	assert.Equal(t, "error", stats[0].Status)
+	assert.Equal(t, "success", stats[1].Status)
+	assert.Equal(t, "error", stats[2].Status)
+
+	_, err = pinConfigFile(context.Background(), filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// newValidateCmd creates the 'config validate' command, which checks the
+// config file for unknown fields, wrong types and semantic mistakes without
+// installing or configuring anything. install and configure run the same
+// checks before they start.
+//
+// Usage:
+//
+//	mycli config validate [flags]
+//
+// Flags:
+//
+//	-c, --config string   Path to the configuration file (default "config.yaml")
+func newValidateCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	var configFile string
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file for mistakes",
+		Args:  cobra.NoArgs,
+		Annotations: map[string]string{
+			"group": "configure",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, _ := tracer.StartSpanFromContext(cmd.Context(), "config_validate")
+			defer span.Finish()
+
+			cs := iostream.ColorScheme()
+			config, err := utils.LoadToolsConfig(configFile)
+			var problems utils.ConfigErrors
+			if errors.As(err, &problems) {
+				for _, problem := range problems {
+					fmt.Fprintln(iostream.ErrOut, cs.Red(problem.Error()))
+				}
+				fmt.Fprintf(iostream.ErrOut, "%s %s: %d problem(s) found\n", cs.FailureIcon(), configFile, len(problems))
+				span.SetTag("problems", len(problems))
+				return utils.SilentError
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load config: %v", err)
+			}
+			fmt.Fprintf(iostream.Out, "%s %s is valid: %d tools, %d configure items\n", cs.SuccessIcon(), configFile, len(config.Tools), len(config.Configure))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	return cmd
+}
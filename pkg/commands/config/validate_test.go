@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCmd(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte(testConfig), 0644))
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("tools:\n  - name: gh\n    post_instal: [gh auth login]\n"), 0644))
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+		stdout  string
+		stderr  string
+	}{
+		{
+			name:   "valid",
+			path:   valid,
+			stdout: valid + " is valid: 2 tools, 2 configure items",
+		},
+		{
+			name:    "invalid",
+			path:    invalid,
+			wantErr: utils.SilentError,
+			stderr:  invalid + `:3:5: unknown field "post_instal" in tools[0], did you mean "post_install"?`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ios, _, stdout, stderr := iostreams.Test()
+			cmd := newValidateCmd(ios)
+			cmd.SetArgs([]string{"-c", tt.path})
+			cmd.SetOut(stdout)
+			cmd.SetErr(stderr)
+			err := cmd.Execute()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Contains(t, stdout.String(), tt.stdout)
+			assert.Contains(t, stderr.String(), tt.stderr)
+		})
+	}
+
+	ios, _, _, _ := iostreams.Test()
+	cmd := newValidateCmd(ios)
+	cmd.SetArgs([]string{"-c", filepath.Join(dir, "missing.yaml")})
+	assert.ErrorContains(t, cmd.Execute(), "failed to load config")
+}
@@ -0,0 +1,290 @@
+package configure
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+)
+
+func archivesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(home, ".mycli", "archives"), nil
+}
+
+// gitHubTreeArchive turns a GitHub tree URL such as
+// https://github.com/owner/repo/tree/main/nvim into the tarball of that ref and
+// the path within it. ok is false for any other URL.
+func gitHubTreeArchive(rawURL string) (archive, path string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host != "github.com" {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[2] != "tree" {
+		return "", "", false
+	}
+	archive = fmt.Sprintf("https://codeload.github.com/%s/%s/tar.gz/%s", parts[0], parts[1], parts[3])
+	return archive, strings.Join(parts[4:], "/"), true
+}
+
+// resolveArchiveSource downloads the item's archive, extracts it into
+// ~/.mycli/archives and points its source_path at archive_path within it, so
+// it installs like any other local source. Archives are extracted once per
+// checksum; a dry run only uses an archive extracted before. It returns the
+// updated item and the archive's SHA-256.
+func resolveArchiveSource(ctx context.Context, item utils.ConfigureItem, dryRun bool) (utils.ConfigureItem, string, error) {
+	archiveURL, path, strip := item.Archive, item.ArchivePath, 0
+	if tarball, treePath, ok := gitHubTreeArchive(item.Archive); ok {
+		// GitHub tarballs wrap everything in a <repo>-<ref> directory.
+		archiveURL, path, strip = tarball, filepath.ToSlash(filepath.Join(treePath, item.ArchivePath)), 1
+	}
+
+	fmt.Printf("Downloading archive from URL: %s\n", utils.RedactURL(item.Archive))
+	content, err := utils.Download(ctx, archiveURL)
+	if err != nil {
+		return item, "", err
+	}
+	if err := utils.VerifySHA256(item.Archive, content, item.SHA256); err != nil {
+		return item, "", err
+	}
+	sum := utils.SHA256Hex(content)
+
+	root, err := archivesDir()
+	if err != nil {
+		return item, "", err
+	}
+	dir := filepath.Join(root, sum)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if dryRun {
+			return item, "", fmt.Errorf("%s is %w as extracted files", utils.RedactURL(item.Archive), utils.ErrNotCached)
+		}
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return item, "", fmt.Errorf("failed to create archive cache: %v", err)
+		}
+		// Extract next to the final location and rename, so an interrupted
+		// extraction is never mistaken for a complete one.
+		tmp, err := os.MkdirTemp(root, ".extract-")
+		if err != nil {
+			return item, "", fmt.Errorf("failed to create archive cache: %v", err)
+		}
+		defer os.RemoveAll(tmp)
+		if err := extractArchive(content, tmp, strip); err != nil {
+			return item, "", fmt.Errorf("failed to extract %s: %v", utils.RedactURL(item.Archive), err)
+		}
+		if err := os.Rename(tmp, dir); err != nil {
+			return item, "", fmt.Errorf("failed to extract %s: %v", utils.RedactURL(item.Archive), err)
+		}
+	}
+
+	source := filepath.Join(dir, filepath.FromSlash(path))
+	if !within(dir, source) {
+		return item, "", fmt.Errorf("archive_path %s is outside the archive", item.ArchivePath)
+	}
+	if _, err := os.Stat(source); err != nil {
+		return item, "", fmt.Errorf("%s not found in %s", path, utils.RedactURL(item.Archive))
+	}
+	item.SourcePath = source
+	return item, sum, nil
+}
+
+// extractArchive extracts a zip, tar or gzipped tar into dest, detected from
+// its content, dropping the first strip path components of every entry.
+func extractArchive(content []byte, dest string, strip int) error {
+	switch {
+	case bytes.HasPrefix(content, []byte("PK\x03\x04")):
+		return extractZip(content, dest, strip)
+	case bytes.HasPrefix(content, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return extractTar(gz, dest, strip)
+	case len(content) > 262 && string(content[257:262]) == "ustar":
+		return extractTar(bytes.NewReader(content), dest, strip)
+	default:
+		return fmt.Errorf("unsupported archive format, expected a zip, tar or tar.gz")
+	}
+}
+
+func extractTar(r io.Reader, dest string, strip int) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, ok, err := archiveTarget(dest, header.Name, strip)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, tr, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := writeArchiveLink(dest, target, header.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			source, ok, err := archiveTarget(dest, header.Linkname, strip)
+			if err != nil || !ok {
+				return fmt.Errorf("archive entry %s links outside the archive", header.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Link(source, target); err != nil {
+				return err
+			}
+		}
+		// Devices, fifos and other special files have no place in a config.
+	}
+}
+
+func extractZip(content []byte, dest string, strip int) error {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		target, ok, err := archiveTarget(dest, f.Name, strip)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		mode := f.Mode()
+		if mode.IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		if mode&os.ModeSymlink != 0 {
+			link, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			if err := writeArchiveLink(dest, target, string(link)); err != nil {
+				return err
+			}
+			continue
+		}
+		err = writeArchiveFile(target, rc, mode.Perm())
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveTarget maps an archive entry name to its path under dest. ok is false
+// for entries removed entirely by strip; entries that would land outside dest
+// are an error.
+func archiveTarget(dest, name string, strip int) (string, bool, error) {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(name), "/"), "/")
+	if len(parts) <= strip {
+		return "", false, nil
+	}
+	rel := filepath.Clean(filepath.FromSlash(strings.Join(parts[strip:], "/")))
+	target := filepath.Join(dest, rel)
+	if filepath.IsAbs(rel) || !within(dest, target) {
+		return "", false, fmt.Errorf("archive entry %s is outside the target directory", name)
+	}
+	// Entries are written through the links extracted before them, so a chain
+	// of links that each stay inside dest, like a/up -> .. and a/up/esc -> ..,
+	// could still lead out of it. Nothing is written through a link at all.
+	if viaSymlink(dest, rel) {
+		return "", false, fmt.Errorf("archive entry %s is written through a symlink", name)
+	}
+	return target, true, nil
+}
+
+// viaSymlink reports whether rel, or any directory leading to it under dest,
+// is already a symlink on disk.
+func viaSymlink(dest, rel string) bool {
+	path := dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		if err != nil {
+			// Nothing below a missing path exists either.
+			return false
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func writeArchiveFile(target string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeArchiveLink creates a symlink, refusing links that point outside dest
+// since later entries could be written through them.
+func writeArchiveLink(dest, target, link string) error {
+	resolved := link
+	if !filepath.IsAbs(link) {
+		resolved = filepath.Join(filepath.Dir(target), link)
+	}
+	if !within(dest, resolved) {
+		return fmt.Errorf("archive link %s points outside the target directory", link)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(link, target)
+}
+
+// within reports whether path is dir or inside it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
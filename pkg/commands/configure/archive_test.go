@@ -0,0 +1,234 @@
+package configure
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type archiveEntry struct {
+	name, body, link string
+	dir              bool
+}
+
+func newTarGz(t *testing.T, entries ...archiveEntry) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.dir:
+			header.Typeflag, header.Mode, header.Size = tar.TypeDir, 0755, 0
+		case e.link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, e.link, 0
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func newZip(t *testing.T, entries ...archiveEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		header.SetMode(0644)
+		body := e.body
+		if e.link != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		}
+		w, err := zw.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		content func(t *testing.T) []byte
+		strip   int
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "Tar.gz with stripped top directory",
+			content: func(t *testing.T) []byte {
+				return newTarGz(t,
+					archiveEntry{name: "dotfiles-main/", dir: true},
+					archiveEntry{name: "dotfiles-main/nvim/init.lua", body: "-- init\n"},
+					archiveEntry{name: "dotfiles-main/nvim/colors.lua", link: "init.lua"},
+				)
+			},
+			strip: 1,
+			want:  map[string]string{"nvim/init.lua": "-- init\n", "nvim/colors.lua": "-- init\n"},
+		},
+		{
+			name: "Zip",
+			content: func(t *testing.T) []byte {
+				return newZip(t, archiveEntry{name: "alacritty/alacritty.toml", body: "[window]\n"})
+			},
+			want: map[string]string{"alacritty/alacritty.toml": "[window]\n"},
+		},
+		{
+			name: "Path traversal",
+			content: func(t *testing.T) []byte {
+				return newTarGz(t, archiveEntry{name: "../evil", body: "x"})
+			},
+			wantErr: "archive entry ../evil is outside the target directory",
+		},
+		{
+			name: "Zip path traversal",
+			content: func(t *testing.T) []byte {
+				return newZip(t, archiveEntry{name: "a/../../evil", body: "x"})
+			},
+			wantErr: "archive entry a/../../evil is outside the target directory",
+		},
+		{
+			name: "Symlink escaping the target",
+			content: func(t *testing.T) []byte {
+				return newTarGz(t, archiveEntry{name: "etc", link: "/etc"})
+			},
+			wantErr: "archive link /etc points outside the target directory",
+		},
+		{
+			name:    "Unsupported format",
+			content: func(t *testing.T) []byte { return []byte("plain text") },
+			wantErr: "unsupported archive format, expected a zip, tar or tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			err := extractArchive(tt.content(t), dest, tt.strip)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for name, body := range tt.want {
+				content, err := os.ReadFile(filepath.Join(dest, name))
+				require.NoError(t, err)
+				assert.Equal(t, body, string(content))
+			}
+		})
+	}
+}
+
+func TestExtractArchiveChainedSymlinks(t *testing.T) {
+	// Each link stays inside the target on its own, but written through one
+	// another they lead to its parent.
+	entries := []archiveEntry{
+		{name: "a/", dir: true},
+		{name: "a/up", link: ".."},
+		{name: "a/up/esc", link: ".."},
+		{name: "a/up/esc/pwned", body: "x"},
+	}
+	for name, content := range map[string][]byte{
+		"tar": newTarGz(t, entries...),
+		"zip": newZip(t, entries[1:]...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "archive")
+			require.NoError(t, os.MkdirAll(dest, 0755))
+
+			err := extractArchive(content, dest, 0)
+			assert.EqualError(t, err, "archive entry a/up/esc is written through a symlink")
+			assert.NoFileExists(t, filepath.Join(parent, "pwned"))
+			assert.NoFileExists(t, filepath.Join(filepath.Dir(parent), "pwned"))
+		})
+	}
+}
+
+func TestGitHubTreeArchive(t *testing.T) {
+	archive, path, ok := gitHubTreeArchive("https://github.com/example/dotfiles/tree/main/config/nvim")
+	assert.True(t, ok)
+	assert.Equal(t, "https://codeload.github.com/example/dotfiles/tar.gz/main", archive)
+	assert.Equal(t, "config/nvim", path)
+
+	_, _, ok = gitHubTreeArchive("https://github.com/example/dotfiles/blob/main/.zshrc")
+	assert.False(t, ok)
+	_, _, ok = gitHubTreeArchive("https://example.com/dotfiles.tar.gz")
+	assert.False(t, ok)
+}
+
+func TestConfigureFromArchive(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	content := newTarGz(t,
+		archiveEntry{name: "nvim/init.lua", body: "-- v2\n"},
+		archiveEntry{name: "nvim/lua/plugins.lua", body: "return {}\n"},
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	target := filepath.Join(home, ".config", "nvim")
+	require.NoError(t, os.MkdirAll(filepath.Join(target, "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "old", "stale.lua"), []byte("-- stale\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "init.lua"), []byte("-- v1\n"), 0644))
+
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "nvim", Archive: server.URL + "/dotfiles.tar.gz", ArchivePath: "nvim", InstallPath: "~/.config/nvim", Prune: true},
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	stats, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{Force: true})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "sha256 "+utils.SHA256Hex(content)[:12], stats[0].Detail)
+
+	got, err := os.ReadFile(filepath.Join(target, "init.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "-- v2\n", string(got))
+	_, err = os.Stat(filepath.Join(target, "lua", "plugins.lua"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(target, "old"))
+	assert.True(t, os.IsNotExist(err), "pruned directory should be removed")
+
+	// The tree was backed up before anything was replaced or pruned.
+	backups, err := listBackups("nvim")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	_, err = restoreBackup(backups[0], utils.BackupPolicy{})
+	require.NoError(t, err)
+	got, err = os.ReadFile(filepath.Join(target, "old", "stale.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "-- stale\n", string(got))
+	_, err = os.Stat(filepath.Join(target, "lua"))
+	assert.True(t, os.IsNotExist(err), "files added since the backup should be removed")
+
+	config.Configure[0].ArchivePath = "../outside"
+	_, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{Force: true})
+	assert.EqualError(t, err, "archive_path ../outside is outside the archive")
+
+	config.Configure[0].ArchivePath = "nvim"
+	config.Configure[0].SHA256 = "0000"
+	_, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{Force: true})
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
@@ -0,0 +1,333 @@
+package configure
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultBackupKeep = 10
+	backupIDLayout    = "20060102-150405.000000"
+	backupMetaFile    = "backup.yaml"
+)
+
+// backup is one saved copy of a config file or directory. Backups live in
+// ~/.mycli/backups/<name>/<id>/, next to a backup.yaml recording where the
+// file came from so it can be restored without the tools config.
+type backup struct {
+	Name    string    `yaml:"-"`
+	ID      string    `yaml:"-"`
+	Path    string    `yaml:"path"`
+	Created time.Time `yaml:"created"`
+}
+
+func (b backup) dir() (string, error) {
+	root, err := backupsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, backupName(b.Name), b.ID), nil
+}
+
+func backupsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(home, ".mycli", "backups"), nil
+}
+
+// backupName turns a configure item name into a single path element.
+func backupName(name string) string {
+	return strings.NewReplacer("/", "_", string(os.PathSeparator), "_").Replace(name)
+}
+
+// backupConfig copies the file or directory at path into the backup store and
+// prunes old backups of name according to policy. It returns the backup
+// directory, or "" when there is nothing at path to back up.
+func backupConfig(name, path string, policy utils.BackupPolicy) (string, error) {
+	dir, err := saveBackup(name, path)
+	if err != nil || dir == "" {
+		return dir, err
+	}
+	if err := pruneBackups(name, policy, ""); err != nil {
+		return dir, err
+	}
+	return dir, nil
+}
+
+// saveBackup is backupConfig without pruning.
+func saveBackup(name, path string) (string, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && !info.Mode().IsRegular() && !info.IsDir()) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+
+	b := backup{Name: name, Path: path, Created: time.Now()}
+	b.ID = b.Created.UTC().Format(backupIDLayout)
+	dir, err := b.dir()
+	if err != nil {
+		return "", err
+	}
+	for i := 1; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		b.ID = fmt.Sprintf("%s-%d", b.Created.UTC().Format(backupIDLayout), i)
+		if dir, err = b.dir(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	save := copyFile
+	if info.IsDir() {
+		save = copyTree
+	}
+	if err := save(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	meta, err := yaml.Marshal(b)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.WriteFileAtomic(filepath.Join(dir, backupMetaFile), meta, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup metadata: %v", err)
+	}
+	return dir, nil
+}
+
+// listBackups returns the backups of name, newest first. An empty name lists
+// the backups of every configure item.
+func listBackups(name string) ([]backup, error) {
+	root, err := backupsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if name != "" {
+		names = []string{backupName(name)}
+	} else {
+		entries, err := os.ReadDir(root)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	var backups []backup
+	for _, n := range names {
+		entries, err := os.ReadDir(filepath.Join(root, n))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			data, err := os.ReadFile(filepath.Join(root, n, entry.Name(), backupMetaFile))
+			if err != nil {
+				continue
+			}
+			b := backup{Name: n, ID: entry.Name()}
+			if err := yaml.Unmarshal(data, &b); err != nil {
+				continue
+			}
+			backups = append(backups, b)
+		}
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].Name != backups[j].Name {
+			return backups[i].Name < backups[j].Name
+		}
+		if !backups[i].Created.Equal(backups[j].Created) {
+			return backups[i].Created.After(backups[j].Created)
+		}
+		return backups[i].ID > backups[j].ID
+	})
+	return backups, nil
+}
+
+// pruneBackups removes backups of name beyond the policy's limits. The newest
+// backup is always kept, however old it is, and so is the backup with ID
+// keepID when set.
+func pruneBackups(name string, policy utils.BackupPolicy, keepID string) error {
+	keep := policy.Keep
+	if keep <= 0 {
+		keep = defaultBackupKeep
+	}
+	maxAge, err := parseMaxAge(policy.MaxAge)
+	if err != nil {
+		return err
+	}
+
+	backups, err := listBackups(name)
+	if err != nil {
+		return err
+	}
+	for i, b := range backups {
+		expired := maxAge > 0 && time.Since(b.Created) > maxAge
+		if i == 0 || (i < keep && !expired) || b.ID == keepID {
+			continue
+		}
+		dir, err := b.dir()
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+// parseMaxAge parses a Go duration, additionally accepting whole days ("30d").
+func parseMaxAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid backups max_age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid backups max_age %q", s)
+	}
+	return d, nil
+}
+
+// findBackup picks the backup of name to restore: the newest one, or with at
+// set, the backup with that ID or the newest one taken at or before that time.
+func findBackup(name, at string) (backup, error) {
+	backups, err := listBackups(name)
+	if err != nil {
+		return backup{}, err
+	}
+	if len(backups) == 0 {
+		return backup{}, fmt.Errorf("no backups found for %s", name)
+	}
+	if at == "" {
+		return backups[0], nil
+	}
+
+	for _, b := range backups {
+		if b.ID == at {
+			return b, nil
+		}
+	}
+	t, err := parseBackupTime(at)
+	if err != nil {
+		return backup{}, err
+	}
+	for _, b := range backups {
+		if !b.Created.After(t) {
+			return b, nil
+		}
+	}
+	return backup{}, fmt.Errorf("no backup of %s taken at or before %s", name, at)
+}
+
+func parseBackupTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(backupIDLayout, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use a backup ID, RFC3339 or YYYY-MM-DD[ HH:MM[:SS]]", s)
+}
+
+// restoreBackup writes b back to its original path. Whatever is currently at
+// that path is backed up first, so a restore can itself be undone. A backed up
+// directory is restored as it was, without files added to it since. Backups
+// of name are then pruned according to policy, never removing b itself.
+func restoreBackup(b backup, policy utils.BackupPolicy) (string, error) {
+	dir, err := b.dir()
+	if err != nil {
+		return "", err
+	}
+	// Pruned only once b is restored, and without b, so that restoring the
+	// oldest backup doesn't remove it first.
+	previous, err := saveBackup(b.Name, b.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := restoreSaved(b, dir); err != nil {
+		return "", err
+	}
+	if err := pruneBackups(b.Name, policy, b.ID); err != nil {
+		return previous, err
+	}
+	return previous, nil
+}
+
+// restoreSaved copies the content of backup b, stored in dir, to b.Path.
+func restoreSaved(b backup, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(b.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	saved := filepath.Join(dir, filepath.Base(b.Path))
+	info, err := os.Stat(saved)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %v", b.Path, err)
+	}
+	if !info.IsDir() {
+		if err := copyFile(saved, b.Path); err != nil {
+			return fmt.Errorf("failed to restore %s: %v", b.Path, err)
+		}
+		return nil
+	}
+	if err := copyTree(saved, b.Path); err != nil {
+		return fmt.Errorf("failed to restore %s: %v", b.Path, err)
+	}
+	if err := pruneTree(saved, b.Path); err != nil {
+		return fmt.Errorf("failed to restore %s: %v", b.Path, err)
+	}
+	return nil
+}
+
+// copyFile atomically copies src to dst, keeping src's permission bits.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	return utils.WriteAtomic(dst, info.Mode().Perm(), func(out *os.File) error {
+		if _, err := io.Copy(out, in); err != nil {
+			return err
+		}
+		return out.Chmod(info.Mode().Perm())
+	})
+}
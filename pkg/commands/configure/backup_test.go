@@ -0,0 +1,144 @@
+package configure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, "init.vim")
+
+	dir, err := backupConfig("neovim", path, utils.BackupPolicy{})
+	require.NoError(t, err)
+	assert.Empty(t, dir, "nothing to back up when the file doesn't exist")
+
+	require.NoError(t, os.WriteFile(path, []byte("set number"), 0640))
+	dir, err = backupConfig("neovim", path, utils.BackupPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".mycli", "backups", "neovim"), filepath.Dir(dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "init.vim"))
+	require.NoError(t, err)
+	assert.Equal(t, "set number", string(content))
+	info, err := os.Stat(filepath.Join(dir, "init.vim"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	backups, err := listBackups("neovim")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, path, backups[0].Path)
+	assert.Equal(t, filepath.Base(dir), backups[0].ID)
+}
+
+func TestPruneBackups(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, "config")
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, os.WriteFile(path, []byte{byte('a' + i)}, 0644))
+		_, err := backupConfig("tool", path, utils.BackupPolicy{Keep: 2})
+		require.NoError(t, err)
+	}
+
+	backups, err := listBackups("tool")
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	latest, err := os.ReadFile(filepath.Join(home, ".mycli", "backups", "tool", backups[0].ID, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, "d", string(latest))
+
+	// An age limit removes everything but the newest backup.
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, pruneBackups("tool", utils.BackupPolicy{MaxAge: "1ms"}, ""))
+	backups, err = listBackups("tool")
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "720h", want: 720 * time.Hour},
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "xd", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseMaxAge(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFindAndRestoreBackup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, "config")
+
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+	first, err := backupConfig("tool", path, utils.BackupPolicy{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	_, err = backupConfig("tool", path, utils.BackupPolicy{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("current"), 0644))
+
+	_, err = findBackup("missing", "")
+	assert.EqualError(t, err, "no backups found for missing")
+
+	latest, err := findBackup("tool", "")
+	require.NoError(t, err)
+	_, err = restoreBackup(latest, utils.BackupPolicy{})
+	require.NoError(t, err)
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "second", string(content))
+
+	byID, err := findBackup("tool", filepath.Base(first))
+	require.NoError(t, err)
+	previous, err := restoreBackup(byID, utils.BackupPolicy{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, previous)
+	content, _ = os.ReadFile(path)
+	assert.Equal(t, "first", string(content))
+
+	// With as many backups as the policy keeps, restoring the oldest must not
+	// prune it before it's read.
+	policy := utils.BackupPolicy{Keep: 2}
+	require.NoError(t, pruneBackups("tool", policy, ""))
+	all, err := listBackups("tool")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	oldest := all[1]
+	require.NoError(t, os.WriteFile(path, []byte("latest"), 0644))
+	_, err = restoreBackup(oldest, policy)
+	require.NoError(t, err)
+	content, _ = os.ReadFile(path)
+	saved, err := os.ReadFile(filepath.Join(home, ".mycli", "backups", "tool", oldest.ID, "config"))
+	require.NoError(t, err, "the restored backup should be kept")
+	assert.Equal(t, string(saved), string(content))
+
+	_, err = findBackup("tool", "2000-01-01")
+	assert.EqualError(t, err, "no backup of tool taken at or before 2000-01-01")
+	_, err = findBackup("tool", "yesterday")
+	assert.ErrorContains(t, err, "invalid time")
+}
@@ -1,13 +1,11 @@
 package configure
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -27,6 +25,10 @@ func NewConfigureCmd(iostream *iostreams.IOStreams) *cobra.Command {
 
 	var configFile string
 	var force bool
+	var diff bool
+	var diffDefault string
+	var offline bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "configure",
@@ -38,7 +40,11 @@ func NewConfigureCmd(iostream *iostreams.IOStreams) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "configure_tools")
 			defer span.Finish()
+			ctx = utils.WithOffline(ctx, offline)
 			nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+			if diffDefault != diffSkip && diffDefault != diffOverwrite {
+				return utils.FlagErrorf("invalid value for --diff-default: %q, expected %q or %q", diffDefault, diffSkip, diffOverwrite)
+			}
 
 			var configPath string
 			var force bool
@@ -81,11 +87,14 @@ func NewConfigureCmd(iostream *iostreams.IOStreams) *cobra.Command {
 
 				}
 
-				stats, err = ConfigureToolsFromConfig(iostream, config, ctx, force)
+				stats, err = ConfigureToolsFromConfig(iostream, config, ctx, ConfigureOptions{Force: force, Diff: diff, DiffDefault: diffDefault, DryRun: dryRun})
 				for _, item := range stats {
 					statsCollector.AddStat(item)
 				}
 				utils.PrintCombinedStats(iostream, statsCollector.GetStats())
+				if err == nil {
+					PrintSSHPublicKeys(iostream, config.SSH)
+				}
 
 				return err
 			} else {
@@ -141,11 +150,14 @@ func NewConfigureCmd(iostream *iostreams.IOStreams) *cobra.Command {
 						return err
 					}
 				}
-				stats, err = ConfigureToolsFromConfig(iostream, config, ctx, force)
+				stats, err = ConfigureToolsFromConfig(iostream, config, ctx, ConfigureOptions{Force: force, Interactive: true, Diff: diff, DiffDefault: diffDefault, DryRun: dryRun})
 				for _, item := range stats {
 					statsCollector.AddStat(item)
 				}
 				utils.PrintCombinedStats(iostream, statsCollector.GetStats())
+				if err == nil {
+					PrintSSHPublicKeys(iostream, config.SSH)
+				}
 
 				return err
 			}
@@ -154,15 +166,64 @@ func NewConfigureCmd(iostream *iostreams.IOStreams) *cobra.Command {
 
 	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Force reconfiguration of tools")
+	cmd.Flags().BoolVar(&diff, "diff", false, "Show a diff and ask before overwriting existing configs")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Use only cached downloads and repositories, never the network")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change, with secrets masked, using only cached downloads and checkouts and without writing anything")
+	cmd.Flags().StringVar(&diffDefault, "diff-default", diffSkip, "Choice for changed configs when prompting isn't possible: skip or overwrite")
+
+	cmd.AddCommand(newBackupsCmd(iostream))
+	cmd.AddCommand(newRestoreCmd(iostream))
+	cmd.AddCommand(newUnlinkCmd(iostream))
+	cmd.AddCommand(newStatusCmd(iostream))
 
 	return cmd
 }
 
-func ConfigureToolsFromConfig(iostream *iostreams.IOStreams, config *utils.ToolConfig, ctx context.Context, force bool) ([]*utils.Stats, error) {
+// ConfigureOptions holds the settings that apply to every item of a configure run.
+type ConfigureOptions struct {
+	Force       bool // Overwrite configs that already exist
+	Interactive bool // Whether the user may be prompted, e.g. for missing template variables
+
+	// vars holds the template variables of the run, seeded from the config and
+	// extended with any values the user was prompted for.
+	vars map[string]string
+	// Diff shows a diff against an existing config and asks whether to
+	// overwrite, skip or merge it instead of skipping it outright.
+	Diff bool
+	// DiffDefault is the choice made for changed configs when prompting isn't
+	// possible: "skip" (the default) or "overwrite".
+	DiffDefault string
+	// DryRun shows what would be written, as a diff with secrets masked,
+	// without changing anything.
+	DryRun bool
+
+	// backups is the retention policy for configs backed up before an overwrite.
+	backups   utils.BackupPolicy
+	iostream  *iostreams.IOStreams
+	checkouts map[string]repoCheckout
+	secrets   *secretResolver
+}
+
+func ConfigureToolsFromConfig(iostream *iostreams.IOStreams, config *utils.ToolConfig, ctx context.Context, opts ConfigureOptions) ([]*utils.Stats, error) {
 	cs := iostream.ColorScheme()
 	var stats []*utils.Stats
 	parentSpan, ctx := tracer.StartSpanFromContext(ctx, "configure_tools")
 	defer parentSpan.Finish()
+	ctx = utils.WithAuth(ctx, config.Auth)
+	// A dry run compares against what was downloaded before, so it neither
+	// touches the network nor writes the caches.
+	if opts.DryRun {
+		ctx = utils.WithOffline(ctx, true)
+	}
+
+	opts.vars = make(map[string]string, len(config.Variables))
+	for key, value := range config.Variables {
+		opts.vars[key] = value
+	}
+	opts.backups = config.Backups
+	opts.iostream = iostream
+	opts.checkouts = make(map[string]repoCheckout)
+	opts.secrets = newSecretResolver(ctx, config.SecretProviders)
 
 	for _, item := range config.Configure {
 		toolSpan, toolCtx := tracer.StartSpanFromContext(ctx, fmt.Sprintf("configure_%s", item.Name))
@@ -175,7 +236,26 @@ func ConfigureToolsFromConfig(iostream *iostreams.IOStreams, config *utils.ToolC
 
 		fmt.Fprintf(iostream.Out, cs.Green("Configuring %s...\n"), item.Name)
 
-		if err := configureTool(item, toolCtx, force); err != nil {
+		var err error
+		if item.Repo != "" {
+			var commit string
+			if item, commit, err = resolveRepoSource(toolCtx, item, opts.checkouts, opts.DryRun); err == nil {
+				toolStat.Detail = "commit " + shortCommit(commit)
+			}
+		} else if item.Archive != "" {
+			var sum string
+			if item, sum, err = resolveArchiveSource(toolCtx, item, opts.DryRun); err == nil {
+				toolStat.Detail = "sha256 " + sum[:12]
+			}
+		}
+		if err == nil {
+			err = configureTool(item, toolCtx, opts)
+		}
+		if opts.DryRun && errors.Is(err, utils.ErrNotCached) {
+			fmt.Fprintf(iostream.Out, "Would download %s, which isn't cached yet\n", utils.RedactURL(remoteSource(item)))
+			err, toolStat.Detail = nil, "dry run, not cached"
+		}
+		if err != nil {
 			fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to configure %s: %v\n"), item.Name, err)
 			toolStat.Status = "error"
 			toolStat.Duration = time.Since(toolStartTime)
@@ -187,6 +267,9 @@ func ConfigureToolsFromConfig(iostream *iostreams.IOStreams, config *utils.ToolC
 
 		toolDuration := time.Since(toolStartTime)
 		toolStat.Status = "success"
+		if opts.DryRun && toolStat.Detail == "" {
+			toolStat.Detail = "dry run"
+		}
 		toolStat.Duration = toolDuration
 		stats = append(stats, &toolStat)
 
@@ -194,40 +277,284 @@ func ConfigureToolsFromConfig(iostream *iostreams.IOStreams, config *utils.ToolC
 		toolSpan.Finish()
 	}
 
+	// ssh comes first, so a signing key for git can be generated in the same run.
+	if config.SSH != nil {
+		fmt.Fprintln(iostream.Out, cs.Green("Configuring ssh..."))
+		start := time.Now()
+		res, err := configureSSH(ctx, config.SSH, opts)
+		sshStat := &utils.Stats{Name: "ssh", Operation: "Configure", Status: "success", Duration: time.Since(start)}
+		if err != nil {
+			fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to configure ssh: %v\n"), err)
+			sshStat.Status = "error"
+			return append(stats, sshStat), err
+		}
+		sshStat.Detail = fmt.Sprintf("%d keys generated, %d hosts set", res.generated, res.hosts)
+		if opts.DryRun {
+			sshStat.Detail = "dry run, " + sshStat.Detail
+		}
+		stats = append(stats, sshStat)
+	}
+
+	if config.Git != nil {
+		fmt.Fprintln(iostream.Out, cs.Green("Configuring git..."))
+		start := time.Now()
+		changed, err := configureGit(ctx, config.Git, opts)
+		gitStat := &utils.Stats{Name: gitStatusName, Operation: "Configure", Status: "success", Duration: time.Since(start)}
+		switch {
+		case err != nil:
+			fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to configure git: %v\n"), err)
+			gitStat.Status = "error"
+			return append(stats, gitStat), err
+		case opts.DryRun:
+			gitStat.Detail = fmt.Sprintf("dry run, %d to set", changed)
+		default:
+			gitStat.Detail = fmt.Sprintf("%d set", changed)
+		}
+		stats = append(stats, gitStat)
+	}
+
+	if opts.DryRun {
+		fmt.Fprintln(iostream.Out, cs.GreenBold("Dry run complete, nothing was changed."))
+		return stats, nil
+	}
 	fmt.Fprintln(iostream.Out, cs.GreenBold("All requested tools have been configured successfully."))
 	return stats, nil
 }
 
-func configureTool(item utils.ConfigureItem, ctx context.Context, force bool) error {
+// remoteSource returns where the item's content is downloaded from.
+func remoteSource(item utils.ConfigureItem) string {
+	switch {
+	case item.Repo != "":
+		return item.Repo
+	case item.Archive != "":
+		return item.Archive
+	}
+	return item.ConfigURL
+}
+
+func configureTool(item utils.ConfigureItem, ctx context.Context, opts ConfigureOptions) error {
 	span, _ := tracer.StartSpanFromContext(ctx, "configure_tool")
 	defer span.Finish()
 
 	installPath := expandTilde(item.InstallPath)
+	perms, err := itemPermissions(item)
+	if err != nil {
+		return err
+	}
+	if err := checkStrategy(item); err != nil {
+		return err
+	}
+	merge := item.Strategy == strategyMerge
+	switch item.Mode {
+	case "", modeCopy:
+	case modeSymlink:
+		if opts.DryRun {
+			fmt.Printf("Would link %s to %s\n", installPath, item.SourcePath)
+			return nil
+		}
+		return linkConfig(item, installPath, opts)
+	default:
+		return fmt.Errorf("unknown mode %q for %s, expected %s or %s", item.Mode, item.Name, modeCopy, modeSymlink)
+	}
+
+	_, statErr := os.Stat(installPath)
+	exists := statErr == nil
+	reviewable := len(item.ConfigureCommand) == 0 && (item.ConfigURL != "" || item.SourcePath != "")
 
-	// Check if file already exists and force flag is not set
-	if _, err := os.Stat(installPath); err == nil && !force {
+	// Check if file already exists and neither force nor diff mode is set.
+	// Merging keeps what's there, so it doesn't need either.
+	if exists && !opts.Force && !(opts.Diff && reviewable) && !merge {
 		fmt.Printf("configuration file already exists at %s. Use --force to overwrite", installPath)
 		return nil
 	}
 
 	// Create the directory if it doesn't exist
-	err := os.MkdirAll(filepath.Dir(installPath), 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
+	if !opts.DryRun {
+		if err := os.MkdirAll(filepath.Dir(installPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %v", err)
+		}
 	}
 
 	if len(item.ConfigureCommand) > 0 {
+		if opts.DryRun {
+			for _, cmd := range item.ConfigureCommand {
+				fmt.Printf("Would execute configure command: %s\n", utils.Redact(cmd))
+			}
+			return nil
+		}
+		if exists {
+			if err := backupBeforeOverwrite(item, installPath, opts); err != nil {
+				return err
+			}
+		}
 		for _, cmd := range item.ConfigureCommand {
 			fmt.Printf("Executing configure command: %s\n", cmd)
 			if err := executeConfigureCommand(ctx, cmd, installPath); err != nil {
 				return err
 			}
 		}
-	} else if item.ConfigURL != "" {
-		fmt.Printf("Downloading config from URL: %s\n", item.ConfigURL)
-		return downloadAndSaveConfig(item.ConfigURL, installPath)
+	} else if item.SourcePath != "" || item.ConfigURL != "" {
+		if item.SourcePath != "" {
+			source, err := sourcePath(item)
+			if err != nil {
+				return err
+			}
+			info, err := os.Stat(source)
+			if err != nil {
+				return fmt.Errorf("source %s does not exist", source)
+			}
+			if info.IsDir() {
+				if merge {
+					return fmt.Errorf("strategy %s for %s needs a single file, %s is a directory", strategyMerge, item.Name, source)
+				}
+				if exists && !opts.Force {
+					fmt.Printf("configuration already exists at %s. Use --force to overwrite", installPath)
+					return nil
+				}
+				if opts.DryRun {
+					fmt.Printf("Would copy %s to %s\n", source, installPath)
+					return nil
+				}
+				// Files copied over or pruned can be restored from the backup.
+				if exists {
+					if err := backupBeforeOverwrite(item, installPath, opts); err != nil {
+						return err
+					}
+				}
+				fmt.Printf("Copying %s to %s\n", source, installPath)
+				if err := copyTree(source, installPath); err != nil {
+					return err
+				}
+				if item.Prune {
+					if err := pruneTree(source, installPath); err != nil {
+						return err
+					}
+				}
+				if err := perms.applyTree(installPath); err != nil {
+					return err
+				}
+				entries, err := treeDigest(source)
+				if err != nil {
+					return err
+				}
+				return recordConfigured(item.Name, installPath, digestHash(entries))
+			}
+			fmt.Printf("Copying config from %s\n", source)
+		} else {
+			fmt.Printf("Downloading config from URL: %s\n", utils.RedactURL(item.ConfigURL))
+		}
+		content, err := sourceContent(ctx, item, opts)
+		if err != nil {
+			return err
+		}
+		// Recorded before review, so a merged file shows up as locally modified.
+		upstream := utils.SHA256Hex(content)
+		if merge && exists {
+			var changed bool
+			if content, changed, err = mergeInstalled(item, installPath, content); err != nil {
+				return err
+			}
+			if !changed && !opts.DryRun {
+				fmt.Printf("%s is already up to date\n", installPath)
+				return recordConfigured(item.Name, installPath, upstream)
+			}
+		}
+		if opts.DryRun {
+			return previewConfig(installPath, content, exists, opts)
+		}
+		if exists {
+			if opts.Diff && !opts.Force {
+				var write bool
+				if content, write, err = reviewChange(ctx, item, installPath, content, opts); err != nil || !write {
+					return err
+				}
+			}
+		}
+		if err := applyParentDir(installPath, perms); err != nil {
+			return err
+		}
+		// Backed up only now that the new content is final, and only if it
+		// differs, so rewriting the same file doesn't push out older backups.
+		if exists {
+			if current, err := os.ReadFile(installPath); err != nil || !bytes.Equal(current, content) {
+				if err := backupBeforeOverwrite(item, installPath, opts); err != nil {
+					return err
+				}
+			}
+		}
+		if err := saveConfig(installPath, content, perms); err != nil {
+			return err
+		}
+		return recordConfigured(item.Name, installPath, upstream)
+	} else {
+		return fmt.Errorf("no configure command, config URL or source path provided for %s", item.Name)
+	}
+	return nil
+}
+
+// sourceContent returns what the item's single-file source would install:
+// source_path or config_url, checked against sha256 and rendered when it is a
+// template.
+func sourceContent(ctx context.Context, item utils.ConfigureItem, opts ConfigureOptions) ([]byte, error) {
+	var content []byte
+	if item.SourcePath != "" {
+		source, err := sourcePath(item)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = os.ReadFile(source); err != nil {
+			return nil, fmt.Errorf("failed to read source: %v", err)
+		}
 	} else {
-		return fmt.Errorf("no configure command or config URL provided for %s", item.Name)
+		var err error
+		if content, err = utils.Download(ctx, item.ConfigURL); err != nil {
+			return nil, err
+		}
+		// Checked before anything is rendered or written, so a mismatch leaves no partial file.
+		if err := utils.VerifySHA256(item.ConfigURL, content, item.SHA256); err != nil {
+			return nil, err
+		}
+	}
+	if item.Template {
+		if opts.vars == nil {
+			opts.vars = make(map[string]string)
+		}
+		var err error
+		if content, err = renderTemplate(item.Name, content, opts.vars, opts.Interactive, opts.secrets); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// previewConfig prints the diff a dry run would apply to installPath.
+func previewConfig(installPath string, content []byte, exists bool, opts ConfigureOptions) error {
+	var current []byte
+	if exists {
+		var err error
+		if current, err = os.ReadFile(installPath); err != nil {
+			return fmt.Errorf("failed to read existing configuration: %v", err)
+		}
+	}
+	diff := utils.UnifiedDiff(installPath, installPath, current, content)
+	if diff == "" {
+		fmt.Printf("%s is already up to date\n", installPath)
+		return nil
+	}
+	fmt.Printf("Would write %s\n", installPath)
+	if opts.iostream != nil {
+		utils.PrintDiff(opts.iostream, utils.Redact(diff))
+	}
+	return nil
+}
+
+func backupBeforeOverwrite(item utils.ConfigureItem, installPath string, opts ConfigureOptions) error {
+	dir, err := backupConfig(item.Name, installPath, opts.backups)
+	if err != nil {
+		return err
+	}
+	if dir != "" {
+		fmt.Printf("Backed up %s to %s\n", installPath, dir)
 	}
 	return nil
 }
@@ -255,7 +582,7 @@ func expandTilde(path string) string {
 
 func executeConfigureCommand(ctx context.Context, command string, installPath string) error {
 	fmt.Printf("Executing command: %s\n", command)
-	cmd := exec.CommandContext(ctx, "zsh", "-c", command)
+	cmd := utils.DetectShell().Command(ctx, command)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
@@ -273,40 +600,28 @@ func executeConfigureCommand(ctx context.Context, command string, installPath st
 	return nil
 }
 
-func downloadAndSaveConfig(configURL, installPath string) error {
-	convertedURL, err := utils.ConvertToRawGitHubURL(configURL)
-	if err != nil {
-		return fmt.Errorf("error converting URL: %v", err)
-	}
-
-	parsedURL, err := url.Parse(convertedURL)
-	if err != nil {
-		return fmt.Errorf("invalid configuration URL: %v", err)
-	}
-
-	if parsedURL.Scheme == "" {
-		return fmt.Errorf("URL scheme is missing. Please provide a complete URL including http:// or https://")
-	}
-
-	resp, err := http.Get(convertedURL)
-	if err != nil {
-		return fmt.Errorf("failed to download configuration: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download configuration: HTTP status %d", resp.StatusCode)
-	}
-
-	out, err := os.Create(installPath)
-	if err != nil {
-		return fmt.Errorf("failed to create configuration file: %v", err)
-	}
-	defer out.Close()
-
-	if _, err = io.Copy(out, resp.Body); err != nil {
-		return fmt.Errorf("failed to write configuration: %v", err)
-	}
-
-	return nil
+// saveConfig writes content to installPath atomically. The file gets its mode
+// and owner before being renamed into place, so the config is never briefly
+// readable with looser permissions. Without file_mode an existing file keeps
+// its mode.
+func saveConfig(installPath string, content []byte, perms permissions) error {
+	return utils.WriteAtomic(installPath, 0644, func(f *os.File) error {
+		// Mode and owner are set before the content is written, so it's
+		// never readable with looser permissions, not even in the temporary
+		// file.
+		if perms.fileMode != 0 {
+			if err := f.Chmod(perms.fileMode); err != nil {
+				return fmt.Errorf("failed to set mode of %s: %v", installPath, err)
+			}
+		}
+		if perms.owned() {
+			if err := f.Chown(perms.uid, perms.gid); err != nil {
+				return fmt.Errorf("failed to set owner of %s: %v", installPath, err)
+			}
+		}
+		if _, err := f.Write(content); err != nil {
+			return fmt.Errorf("failed to write configuration: %v", err)
+		}
+		return nil
+	})
 }
@@ -27,6 +27,7 @@ func TestNewConfigureToolsCmd(t *testing.T) {
 }
 
 func TestConfigureToolsFromConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	// Create a temporary directory for test files
 	tempDir, err := os.MkdirTemp("", "test-configure")
 	require.NoError(t, err)
@@ -53,7 +54,7 @@ func TestConfigureToolsFromConfig(t *testing.T) {
 	}
 
 	ios, _, stdout, stderr := iostreams.Test()
-	_, err = ConfigureToolsFromConfig(ios, config, context.Background(), false)
+	_, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
 
 	assert.NoError(t, err)
 	assert.Contains(t, stdout.String(), "Configuring test-tool...")
@@ -71,6 +72,7 @@ func TestConfigureTool(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "test-configure-tool")
 	require.NoError(t, err)
 	defer os.RemoveAll(tempDir)
+	t.Setenv("HOME", tempDir)
 
 	// Create a test server to serve configuration files
 	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -137,7 +139,7 @@ func TestConfigureTool(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			err := configureTool(tc.item, context.Background(), tc.force)
+			err := configureTool(tc.item, context.Background(), ConfigureOptions{Force: tc.force})
 
 			if tc.expectError {
 				assert.Error(t, err)
@@ -152,6 +154,12 @@ func TestConfigureTool(t *testing.T) {
 				} else {
 					if tc.item.Name == "existing-tool" && !tc.force {
 						assert.Equal(t, "existing content", string(content))
+					} else if tc.item.Name == "existing-tool" {
+						assert.Equal(t, "test configuration content", string(content))
+						backups, err := listBackups(tc.item.Name)
+						require.NoError(t, err)
+						require.Len(t, backups, 1)
+						assert.Equal(t, tc.item.InstallPath, backups[0].Path)
 					} else {
 						assert.Equal(t, "test configuration content", string(content))
 					}
@@ -183,3 +191,113 @@ func TestExpandTilde(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigureToolChecksum(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("set number\n"))
+	}))
+	defer testServer.Close()
+
+	installPath := filepath.Join(tempDir, "init.vim")
+	item := utils.ConfigureItem{Name: "neovim", ConfigURL: testServer.URL, InstallPath: installPath, SHA256: utils.SHA256Hex([]byte("set number\n"))}
+	require.NoError(t, configureTool(item, context.Background(), ConfigureOptions{}))
+	content, err := os.ReadFile(installPath)
+	require.NoError(t, err)
+	assert.Equal(t, "set number\n", string(content))
+
+	mismatched := filepath.Join(tempDir, "mismatched.vim")
+	item = utils.ConfigureItem{Name: "neovim", ConfigURL: testServer.URL, InstallPath: mismatched, SHA256: utils.SHA256Hex([]byte("old"))}
+	err = configureTool(item, context.Background(), ConfigureOptions{})
+	assert.ErrorContains(t, err, "checksum mismatch for "+testServer.URL)
+	_, err = os.Stat(mismatched)
+	assert.True(t, os.IsNotExist(err), "no file is written when the checksum doesn't match")
+}
+
+func TestConfigureToolBackups(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	body := "set number\n"
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer testServer.Close()
+
+	installPath := filepath.Join(home, "init.vim")
+	require.NoError(t, os.WriteFile(installPath, []byte("mine\n"), 0644))
+	item := utils.ConfigureItem{Name: "neovim", ConfigURL: testServer.URL, InstallPath: installPath}
+	backups := func() int {
+		list, err := listBackups("neovim")
+		require.NoError(t, err)
+		return len(list)
+	}
+
+	require.NoError(t, configureTool(item, context.Background(), ConfigureOptions{Force: true}))
+	assert.Equal(t, 1, backups())
+
+	// Writing the same content again needs no backup.
+	require.NoError(t, configureTool(item, context.Background(), ConfigureOptions{Force: true}))
+	assert.Equal(t, 1, backups())
+
+	// Nor does a download that fails, as nothing is written.
+	body = ""
+	require.Error(t, configureTool(item, context.Background(), ConfigureOptions{Force: true}))
+	assert.Equal(t, 1, backups())
+}
+
+func TestConfigureToolsFromConfigOffline(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("cached content"))
+	}))
+
+	item := utils.ConfigureItem{Name: "cached", ConfigURL: testServer.URL + "/cached", InstallPath: filepath.Join(home, "cached")}
+	require.NoError(t, configureTool(item, context.Background(), ConfigureOptions{}))
+	testServer.Close()
+
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			item,
+			{Name: "uncached", ConfigURL: testServer.URL + "/uncached", InstallPath: filepath.Join(home, "uncached")},
+		},
+	}
+	ios, _, _, stderr := iostreams.Test()
+	ctx := utils.WithOffline(context.Background(), true)
+	stats, err := ConfigureToolsFromConfig(ios, config, ctx, ConfigureOptions{Force: true})
+	assert.EqualError(t, err, testServer.URL+"/uncached is not cached, run once without --offline to download it")
+	assert.Contains(t, stderr.String(), "Failed to configure uncached")
+	require.Len(t, stats, 2)
+	assert.Equal(t, "success", stats[0].Status)
+	assert.Equal(t, "error", stats[1].Status)
+}
+
+func TestConfigureToolsFromConfigDryRun(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte("new content"))
+	}))
+	defer testServer.Close()
+
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "app", ConfigURL: testServer.URL + "/app", InstallPath: filepath.Join(home, "app")},
+		},
+	}
+	ios, _, stdout, _ := iostreams.Test()
+	stats, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Zero(t, requests, "a dry run must not download")
+	assert.Contains(t, stdout.String(), "Would download "+testServer.URL+"/app, which isn't cached yet")
+	assert.Equal(t, "dry run, not cached", stats[0].Detail)
+	assert.NoDirExists(t, filepath.Join(home, ".mycli", "cache"))
+	assert.NoFileExists(t, filepath.Join(home, "app"))
+}
@@ -0,0 +1,117 @@
+package configure
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+const (
+	diffOverwrite = "overwrite"
+	diffSkip      = "skip"
+	diffMerge     = "merge"
+)
+
+// runEditor opens path in the user's editor and waits for it to exit.
+var runEditor = func(ctx context.Context, path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// reviewChange shows how content differs from the config at installPath and
+// decides what to write. It returns the content to save and whether to save it.
+func reviewChange(ctx context.Context, item utils.ConfigureItem, installPath string, content []byte, opts ConfigureOptions) ([]byte, bool, error) {
+	cs := opts.iostream.ColorScheme()
+	current, err := os.ReadFile(installPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read existing configuration: %v", err)
+	}
+
+	newName := item.ConfigURL
+	if item.SourcePath != "" {
+		newName = item.SourcePath
+	}
+	diff := utils.UnifiedDiff(installPath, newName, current, content)
+	if diff == "" {
+		fmt.Fprintf(opts.iostream.Out, "%s is already up to date\n", installPath)
+		return nil, false, nil
+	}
+	utils.PrintDiff(opts.iostream, utils.Redact(diff))
+
+	choice := opts.DiffDefault
+	if choice == "" {
+		choice = diffSkip
+	}
+	if opts.Interactive {
+		prompt := &survey.Select{
+			Message: fmt.Sprintf("%s has changed. What do you want to do?", installPath),
+			Options: []string{diffOverwrite, diffSkip, diffMerge},
+			Default: diffSkip,
+		}
+		if err := askOne(prompt, &choice); err != nil {
+			return nil, false, err
+		}
+	}
+
+	switch choice {
+	case diffOverwrite:
+		return content, true, nil
+	case diffMerge:
+		merged, err := mergeInEditor(ctx, installPath, current, content)
+		if err != nil {
+			return nil, false, err
+		}
+		return merged, true, nil
+	default:
+		fmt.Fprintf(opts.iostream.Out, cs.Yellow("Skipped %s\n"), installPath)
+		return nil, false, nil
+	}
+}
+
+// mergeInEditor writes current and content with conflict markers around each
+// change to a temporary file, lets the user resolve them in $EDITOR and
+// returns the result.
+func mergeInEditor(ctx context.Context, installPath string, current, content []byte) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "mycli-merge-*-"+filepath.Base(installPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merge file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(utils.ConflictMarkers("current", "new", current, content)); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write merge file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write merge file: %v", err)
+	}
+
+	if err := runEditor(ctx, tmp.Name()); err != nil {
+		return nil, fmt.Errorf("editor exited with an error: %v", err)
+	}
+
+	merged, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merge file: %v", err)
+	}
+	if utils.HasConflictMarkers(merged) {
+		return nil, fmt.Errorf("unresolved conflict markers left in %s, keeping the existing config", installPath)
+	}
+	return merged, nil
+}
@@ -0,0 +1,120 @@
+package configure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureDiff(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("set number\nset relativenumber\n"))
+	}))
+	defer testServer.Close()
+
+	tests := []struct {
+		name        string
+		existing    string
+		opts        ConfigureOptions
+		choice      string
+		edited      string
+		wantContent string
+		wantOut     string
+		wantErr     string
+	}{
+		{
+			name:        "unchanged",
+			existing:    "set number\nset relativenumber\n",
+			opts:        ConfigureOptions{Diff: true},
+			wantContent: "set number\nset relativenumber\n",
+			wantOut:     "is already up to date",
+		},
+		{
+			name:        "non-interactive skips by default",
+			existing:    "set number\n",
+			opts:        ConfigureOptions{Diff: true},
+			wantContent: "set number\n",
+			wantOut:     "+set relativenumber",
+		},
+		{
+			name:        "non-interactive default overwrite",
+			existing:    "set number\n",
+			opts:        ConfigureOptions{Diff: true, DiffDefault: diffOverwrite},
+			wantContent: "set number\nset relativenumber\n",
+		},
+		{
+			name:        "interactive skip",
+			existing:    "set number\n",
+			opts:        ConfigureOptions{Diff: true, Interactive: true},
+			choice:      diffSkip,
+			wantContent: "set number\n",
+			wantOut:     "Skipped",
+		},
+		{
+			name:        "interactive merge",
+			existing:    "set number\nset mouse=a\n",
+			opts:        ConfigureOptions{Diff: true, Interactive: true},
+			choice:      diffMerge,
+			edited:      "set number\nset mouse=a\nset relativenumber\n",
+			wantContent: "set number\nset mouse=a\nset relativenumber\n",
+		},
+		{
+			name:        "merge with unresolved conflicts",
+			existing:    "set number\nset mouse=a\n",
+			opts:        ConfigureOptions{Diff: true, Interactive: true},
+			choice:      diffMerge,
+			wantContent: "set number\nset mouse=a\n",
+			wantErr:     "unresolved conflict markers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			path := filepath.Join(home, "init.vim")
+			require.NoError(t, os.WriteFile(path, []byte(tt.existing), 0644))
+
+			oldAskOne, oldRunEditor := askOne, runEditor
+			defer func() { askOne, runEditor = oldAskOne, oldRunEditor }()
+			askOne = func(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+				*(response.(*string)) = tt.choice
+				return nil
+			}
+			runEditor = func(ctx context.Context, file string) error {
+				content, err := os.ReadFile(file)
+				require.NoError(t, err)
+				assert.True(t, utils.HasConflictMarkers(content))
+				if tt.edited != "" {
+					return os.WriteFile(file, []byte(tt.edited), 0644)
+				}
+				return nil
+			}
+
+			config := &utils.ToolConfig{
+				Configure: []utils.ConfigureItem{{Name: "neovim", ConfigURL: testServer.URL, InstallPath: path}},
+			}
+			ios, _, stdout, _ := iostreams.Test()
+			_, err := ConfigureToolsFromConfig(ios, config, context.Background(), tt.opts)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContent, string(content))
+			assert.Contains(t, stdout.String(), tt.wantOut)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package configure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/AlecAivazis/survey/v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// gitStatusName is the row the git section gets in configure status.
+const gitStatusName = "git config"
+
+// gitSetting is a key of the global git config and the value the git section
+// wants it to have.
+type gitSetting struct {
+	key   string
+	value string
+}
+
+// gitSettings lists what the git section sets, in a stable order. Name and
+// email are only included when configured.
+func gitSettings(cfg *utils.GitConfig) ([]gitSetting, error) {
+	var settings []gitSetting
+	add := func(key, value string) {
+		if value != "" {
+			settings = append(settings, gitSetting{key: key, value: value})
+		}
+	}
+	add("user.name", cfg.Name)
+	add("user.email", cfg.Email)
+	if cfg.SigningKey != "" {
+		add("user.signingkey", expandTilde(cfg.SigningKey))
+		add("commit.gpgsign", "true")
+		add("tag.gpgsign", "true")
+	}
+	switch cfg.SigningFormat {
+	case "", "openpgp", "ssh", "x509":
+		add("gpg.format", cfg.SigningFormat)
+	default:
+		return nil, fmt.Errorf("unknown git signing_format %q, expected openpgp, ssh or x509", cfg.SigningFormat)
+	}
+	add("init.defaultBranch", cfg.DefaultBranch)
+	for _, name := range sortedKeys(cfg.Aliases) {
+		add("alias."+name, cfg.Aliases[name])
+	}
+	for _, include := range cfg.IncludeIf {
+		if include.Dir == "" || include.Path == "" {
+			return nil, fmt.Errorf("git include_if entries need both dir and path")
+		}
+		// The trailing slash makes gitdir match every repository below dir.
+		dir := strings.TrimSuffix(include.Dir, "/") + "/"
+		add("includeIf.gitdir:"+dir+".path", include.Path)
+	}
+	return settings, nil
+}
+
+// configureGit applies the git section with git config --global, only
+// touching keys that differ. It returns how many keys it changed, or would
+// change in a dry run.
+func configureGit(ctx context.Context, cfg *utils.GitConfig, opts ConfigureOptions) (int, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "configure_git")
+	defer span.Finish()
+
+	settings, err := gitSettings(cfg)
+	if err != nil {
+		return 0, err
+	}
+	identity, err := gitIdentity(ctx, cfg, opts)
+	if err != nil {
+		return 0, err
+	}
+	settings = append(identity, settings...)
+
+	changed := 0
+	for _, s := range settings {
+		current, err := gitConfigGet(ctx, s.key)
+		if err != nil {
+			return changed, err
+		}
+		if current == s.value {
+			continue
+		}
+		changed++
+		if opts.DryRun {
+			fmt.Printf("Would set git %s to %q (currently %q)\n", s.key, s.value, current)
+			continue
+		}
+		fmt.Printf("Setting git %s to %q\n", s.key, s.value)
+		if out, err := execCommandContext(ctx, "git", "config", "--global", s.key, s.value).CombinedOutput(); err != nil {
+			return changed, fmt.Errorf("failed to set git %s: %v: %s", s.key, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return changed, nil
+}
+
+// gitIdentity returns the user.name and user.email settings to apply when the
+// git section leaves them out: nothing if git already has them, otherwise
+// what the user answers when prompted.
+func gitIdentity(ctx context.Context, cfg *utils.GitConfig, opts ConfigureOptions) ([]gitSetting, error) {
+	var settings []gitSetting
+	for _, field := range []struct{ key, value, name string }{
+		{"user.name", cfg.Name, "name"},
+		{"user.email", cfg.Email, "email"},
+	} {
+		if field.value != "" {
+			continue
+		}
+		current, err := gitConfigGet(ctx, field.key)
+		if err != nil {
+			return nil, err
+		}
+		if current != "" {
+			continue
+		}
+		if !opts.Interactive {
+			return nil, fmt.Errorf("git %s is not set, add %s to the git section of the config", field.key, field.name)
+		}
+		var value string
+		prompt := &survey.Input{Message: fmt.Sprintf("Your git %s:", field.name)}
+		if err := askOne(prompt, &value, survey.WithValidator(survey.Required)); err != nil {
+			return nil, err
+		}
+		settings = append(settings, gitSetting{key: field.key, value: value})
+	}
+	return settings, nil
+}
+
+// gitConfigGet returns the global value of key, or "" when it isn't set.
+func gitConfigGet(ctx context.Context, key string) (string, error) {
+	out, err := execCommandContext(ctx, "git", "config", "--global", "--get", key).Output()
+	var exitErr *exec.ExitError
+	// git exits with 1 for a key that isn't set.
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read git %s: %v", key, err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// gitStatus compares the global git config with the git section.
+func gitStatus(ctx context.Context, cfg *utils.GitConfig) itemStatus {
+	st := itemStatus{Name: gitStatusName, Path: "git config --global"}
+	settings, err := gitSettings(cfg)
+	if err != nil {
+		st.Status, st.Detail = statusError, err.Error()
+		return st
+	}
+	var drifted []string
+	for _, s := range settings {
+		current, err := gitConfigGet(ctx, s.key)
+		if err != nil {
+			st.Status, st.Detail = statusError, err.Error()
+			return st
+		}
+		if current != s.value {
+			drifted = append(drifted, fmt.Sprintf("%s is %q, expected %q", s.key, current, s.value))
+		}
+	}
+	st.Status = statusInSync
+	if len(drifted) > 0 {
+		st.Status, st.Detail = statusLocallyModified, strings.Join(drifted, "; ")
+	}
+	return st
+}
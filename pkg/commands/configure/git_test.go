@@ -0,0 +1,117 @@
+package configure
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupGitHome points git's global config at an empty temporary home.
+func setupGitHome(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	return home
+}
+
+func TestGitSettings(t *testing.T) {
+	t.Setenv("HOME", "/home/jane")
+	settings, err := gitSettings(&utils.GitConfig{
+		Email:         "jane@example.com",
+		SigningKey:    "~/.ssh/id_ed25519.pub",
+		SigningFormat: "ssh",
+		DefaultBranch: "main",
+		Aliases:       map[string]string{"st": "status -sb", "co": "checkout"},
+		IncludeIf:     []utils.GitInclude{{Dir: "~/work", Path: "~/.gitconfig-work"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []gitSetting{
+		{"user.email", "jane@example.com"},
+		{"user.signingkey", "/home/jane/.ssh/id_ed25519.pub"},
+		{"commit.gpgsign", "true"},
+		{"tag.gpgsign", "true"},
+		{"gpg.format", "ssh"},
+		{"init.defaultBranch", "main"},
+		{"alias.co", "checkout"},
+		{"alias.st", "status -sb"},
+		{"includeIf.gitdir:~/work/.path", "~/.gitconfig-work"},
+	}, settings)
+
+	_, err = gitSettings(&utils.GitConfig{SigningFormat: "pgp"})
+	assert.EqualError(t, err, `unknown git signing_format "pgp", expected openpgp, ssh or x509`)
+	_, err = gitSettings(&utils.GitConfig{IncludeIf: []utils.GitInclude{{Dir: "~/work"}}})
+	assert.EqualError(t, err, "git include_if entries need both dir and path")
+}
+
+func TestConfigureGit(t *testing.T) {
+	home := setupGitHome(t)
+	config := &utils.ToolConfig{
+		Git: &utils.GitConfig{
+			Email:         "jane@example.com",
+			DefaultBranch: "main",
+			Aliases:       map[string]string{"co": "checkout"},
+			IncludeIf:     []utils.GitInclude{{Dir: "~/work/", Path: "~/.gitconfig-work"}},
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+
+	// Without a name in the config or git, non-interactive runs fail.
+	_, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	assert.EqualError(t, err, "git user.name is not set, add name to the git section of the config")
+
+	originalAskOne := askOne
+	defer func() { askOne = originalAskOne }()
+	askOne = func(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+		*(response.(*string)) = "Jane Doe"
+		return nil
+	}
+
+	stats, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{Interactive: true, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, "dry run, 5 to set", stats[0].Detail)
+	_, err = os.Stat(filepath.Join(home, ".gitconfig"))
+	assert.True(t, os.IsNotExist(err), "dry run should not write the git config")
+
+	stats, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{Interactive: true})
+	require.NoError(t, err)
+	assert.Equal(t, "5 set", stats[0].Detail)
+
+	out, err := exec.Command("git", "config", "--global", "--list").Output()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"user.name=Jane Doe",
+		"user.email=jane@example.com",
+		"init.defaultbranch=main",
+		"alias.co=checkout",
+		"includeif.gitdir:~/work/.path=~/.gitconfig-work",
+	}, strings.Split(strings.TrimSpace(string(out)), "\n"))
+
+	// A second run changes nothing and no longer needs to prompt.
+	stats, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "0 set", stats[0].Detail)
+
+	statuses, err := configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, itemStatus{Name: gitStatusName, Path: "git config --global", Status: statusInSync}, statuses[0])
+
+	require.NoError(t, exec.Command("git", "config", "--global", "init.defaultBranch", "master").Run())
+	statuses, err = configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusLocallyModified, statuses[0].Status)
+	assert.Equal(t, `init.defaultBranch is "master", expected "main"`, statuses[0].Detail)
+}
@@ -0,0 +1,198 @@
+package configure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+)
+
+const (
+	modeCopy    = "copy"
+	modeSymlink = "symlink"
+)
+
+// sourcePath returns the absolute path of the item's source_path.
+func sourcePath(item utils.ConfigureItem) (string, error) {
+	source, err := filepath.Abs(expandTilde(item.SourcePath))
+	if err != nil {
+		return "", fmt.Errorf("invalid source_path %s: %v", item.SourcePath, err)
+	}
+	return source, nil
+}
+
+// linkTarget returns where the symlink at path points, resolved against the
+// link's directory, and whether that target exists.
+func linkTarget(path string) (string, bool, error) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", false, err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	_, err = os.Stat(path)
+	return filepath.Clean(target), err == nil, nil
+}
+
+// linkConfig makes installPath a symlink to the item's source_path, stow-style.
+// An existing real file is a conflict unless force is set, in which case it is
+// backed up first; an existing directory is always a conflict. Links pointing
+// elsewhere are only replaced with force, broken links always are.
+func linkConfig(item utils.ConfigureItem, installPath string, opts ConfigureOptions) error {
+	if item.SourcePath == "" {
+		return fmt.Errorf("source_path is required for mode %s", modeSymlink)
+	}
+	source, err := sourcePath(item)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(source); err != nil {
+		return fmt.Errorf("source %s does not exist", source)
+	}
+
+	info, err := os.Lstat(installPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		target, ok, err := linkTarget(installPath)
+		if err != nil {
+			return err
+		}
+		switch {
+		case target == source:
+			fmt.Printf("%s already links to %s\n", installPath, source)
+			return nil
+		case !ok:
+			fmt.Printf("Replacing broken link %s -> %s\n", installPath, target)
+		case !opts.Force:
+			return fmt.Errorf("conflict: %s links to %s, use --force to replace it", installPath, target)
+		}
+		if err := os.Remove(installPath); err != nil {
+			return fmt.Errorf("failed to remove link: %v", err)
+		}
+	case info.IsDir():
+		return fmt.Errorf("conflict: %s is an existing directory, move it away to link it", installPath)
+	default:
+		if !opts.Force {
+			return fmt.Errorf("conflict: %s is an existing file, use --force to back it up and link it", installPath)
+		}
+		if err := backupBeforeOverwrite(item, installPath, opts); err != nil {
+			return err
+		}
+		if err := os.Remove(installPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", installPath, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(installPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := os.Symlink(source, installPath); err != nil {
+		return fmt.Errorf("failed to link %s: %v", installPath, err)
+	}
+	fmt.Printf("Linked %s -> %s\n", installPath, source)
+	return nil
+}
+
+// unlinkConfig removes installPath if it is a symlink to the item's
+// source_path. It returns a short description of what it did.
+func unlinkConfig(item utils.ConfigureItem) (string, error) {
+	installPath := expandTilde(item.InstallPath)
+	source, err := sourcePath(item)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(installPath)
+	if os.IsNotExist(err) {
+		return "not linked", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return "", fmt.Errorf("%s is not a symlink, leaving it in place", installPath)
+	}
+	target, ok, err := linkTarget(installPath)
+	if err != nil {
+		return "", err
+	}
+	if target != source {
+		return "", fmt.Errorf("%s links to %s, not %s, leaving it in place", installPath, target, source)
+	}
+	if err := os.Remove(installPath); err != nil {
+		return "", fmt.Errorf("failed to remove link: %v", err)
+	}
+	if !ok {
+		return "removed broken link", nil
+	}
+	return "unlinked", nil
+}
+
+// copyTree copies the directory src to dst, overwriting files that exist in both.
+// Git metadata is not copied.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir() && info.Name() == ".git" && path != src:
+			return filepath.SkipDir
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target)
+		}
+	})
+}
+
+// pruneTree removes everything under dst that has no counterpart under src,
+// leaving dst a mirror of src. Like copyTree, it leaves .git directories alone.
+func pruneTree(src, dst string) error {
+	return filepath.Walk(dst, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dst {
+			return nil
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(dst, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(src, rel)); !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Printf("Removing %s\n", path)
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
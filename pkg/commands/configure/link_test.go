@@ -0,0 +1,144 @@
+package configure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dotfiles := filepath.Join(home, "dotfiles")
+	require.NoError(t, os.MkdirAll(filepath.Join(dotfiles, "nvim"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dotfiles, "zshrc"), []byte("export EDITOR=nvim\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dotfiles, "nvim", "init.lua"), []byte("-- init\n"), 0644))
+
+	zshrc := utils.ConfigureItem{Name: "zsh", SourcePath: "~/dotfiles/zshrc", InstallPath: "~/.zshrc", Mode: modeSymlink}
+	nvim := utils.ConfigureItem{Name: "nvim", SourcePath: "~/dotfiles/nvim", InstallPath: "~/.config/nvim", Mode: modeSymlink}
+	linkPath := filepath.Join(home, ".zshrc")
+
+	t.Run("creates file and directory links", func(t *testing.T) {
+		require.NoError(t, configureTool(zshrc, context.Background(), ConfigureOptions{}))
+		require.NoError(t, configureTool(nvim, context.Background(), ConfigureOptions{}))
+
+		target, err := os.Readlink(linkPath)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dotfiles, "zshrc"), target)
+		content, err := os.ReadFile(filepath.Join(home, ".config", "nvim", "init.lua"))
+		require.NoError(t, err)
+		assert.Equal(t, "-- init\n", string(content))
+
+		// Linking again is a no-op.
+		require.NoError(t, configureTool(zshrc, context.Background(), ConfigureOptions{}))
+	})
+
+	t.Run("link elsewhere is a conflict without force", func(t *testing.T) {
+		require.NoError(t, os.Remove(linkPath))
+		require.NoError(t, os.Symlink(filepath.Join(dotfiles, "nvim"), linkPath))
+		err := configureTool(zshrc, context.Background(), ConfigureOptions{})
+		assert.ErrorContains(t, err, "conflict")
+		require.NoError(t, configureTool(zshrc, context.Background(), ConfigureOptions{Force: true}))
+		target, _ := os.Readlink(linkPath)
+		assert.Equal(t, filepath.Join(dotfiles, "zshrc"), target)
+	})
+
+	t.Run("broken link is replaced", func(t *testing.T) {
+		require.NoError(t, os.Remove(linkPath))
+		require.NoError(t, os.Symlink(filepath.Join(home, "gone"), linkPath))
+		require.NoError(t, configureTool(zshrc, context.Background(), ConfigureOptions{}))
+		target, _ := os.Readlink(linkPath)
+		assert.Equal(t, filepath.Join(dotfiles, "zshrc"), target)
+	})
+
+	t.Run("real file is backed up with force", func(t *testing.T) {
+		require.NoError(t, os.Remove(linkPath))
+		require.NoError(t, os.WriteFile(linkPath, []byte("hand tuned"), 0644))
+		err := configureTool(zshrc, context.Background(), ConfigureOptions{})
+		assert.ErrorContains(t, err, "is an existing file")
+
+		require.NoError(t, configureTool(zshrc, context.Background(), ConfigureOptions{Force: true}))
+		backups, err := listBackups("zsh")
+		require.NoError(t, err)
+		assert.Len(t, backups, 1)
+	})
+
+	t.Run("real directory is a conflict", func(t *testing.T) {
+		dir := utils.ConfigureItem{Name: "dir", SourcePath: "~/dotfiles/nvim", InstallPath: "~/dotfiles", Mode: modeSymlink}
+		err := configureTool(dir, context.Background(), ConfigureOptions{Force: true})
+		assert.ErrorContains(t, err, "is an existing directory")
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		item := utils.ConfigureItem{Name: "x", SourcePath: "~/dotfiles/missing", InstallPath: "~/.x", Mode: modeSymlink}
+		err := configureTool(item, context.Background(), ConfigureOptions{})
+		assert.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		item := utils.ConfigureItem{Name: "x", SourcePath: "~/dotfiles/zshrc", InstallPath: "~/.x", Mode: "hardlink"}
+		err := configureTool(item, context.Background(), ConfigureOptions{})
+		assert.EqualError(t, err, `unknown mode "hardlink" for x, expected copy or symlink`)
+	})
+}
+
+func TestCopySourcePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dotfiles := filepath.Join(home, "dotfiles")
+	require.NoError(t, os.MkdirAll(filepath.Join(dotfiles, "nvim", "lua"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dotfiles, "zshrc"), []byte("export EDITOR=nvim\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dotfiles, "nvim", "lua", "plugins.lua"), []byte("return {}\n"), 0644))
+
+	// A copy replaces a link left behind by symlink mode instead of writing through it.
+	require.NoError(t, os.Symlink(filepath.Join(dotfiles, "zshrc"), filepath.Join(home, ".zshrc")))
+	file := utils.ConfigureItem{Name: "zsh", SourcePath: "~/dotfiles/zshrc", InstallPath: "~/.zshrc"}
+	require.NoError(t, configureTool(file, context.Background(), ConfigureOptions{Force: true}))
+	info, err := os.Lstat(filepath.Join(home, ".zshrc"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+
+	dir := utils.ConfigureItem{Name: "nvim", SourcePath: "~/dotfiles/nvim", InstallPath: "~/.config/nvim", Mode: modeCopy}
+	require.NoError(t, configureTool(dir, context.Background(), ConfigureOptions{}))
+	content, err := os.ReadFile(filepath.Join(home, ".config", "nvim", "lua", "plugins.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "return {}\n", string(content))
+}
+
+func TestUnlinkConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	source := filepath.Join(home, "zshrc")
+	require.NoError(t, os.WriteFile(source, []byte(""), 0644))
+	item := utils.ConfigureItem{Name: "zsh", SourcePath: source, InstallPath: "~/.zshrc", Mode: modeSymlink}
+	linkPath := filepath.Join(home, ".zshrc")
+
+	result, err := unlinkConfig(item)
+	require.NoError(t, err)
+	assert.Equal(t, "not linked", result)
+
+	require.NoError(t, os.Symlink(source, linkPath))
+	result, err = unlinkConfig(item)
+	require.NoError(t, err)
+	assert.Equal(t, "unlinked", result)
+
+	require.NoError(t, os.Symlink(source, linkPath))
+	require.NoError(t, os.Remove(source))
+	result, err = unlinkConfig(item)
+	require.NoError(t, err)
+	assert.Equal(t, "removed broken link", result)
+
+	require.NoError(t, os.Symlink(filepath.Join(home, "other"), linkPath))
+	_, err = unlinkConfig(item)
+	assert.ErrorContains(t, err, "leaving it in place")
+
+	require.NoError(t, os.Remove(linkPath))
+	require.NoError(t, os.WriteFile(linkPath, []byte("real"), 0644))
+	_, err = unlinkConfig(item)
+	assert.ErrorContains(t, err, "is not a symlink")
+}
@@ -0,0 +1,969 @@
+package configure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	strategyReplace = "replace"
+	strategyMerge   = "merge"
+)
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+	formatINI  = "ini"
+)
+
+// mergeFormat returns the format an item with strategy merge is merged as:
+// its format field, or else what install_path's extension suggests.
+func mergeFormat(item utils.ConfigureItem, installPath string) (string, error) {
+	switch item.Format {
+	case formatJSON, formatYAML, formatTOML, formatINI:
+		return item.Format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown format %q for %s, expected %s, %s, %s or %s", item.Format, item.Name, formatJSON, formatYAML, formatTOML, formatINI)
+	}
+	switch strings.ToLower(filepath.Ext(installPath)) {
+	case ".json", ".jsonc", ".code-workspace":
+		return formatJSON, nil
+	case ".yaml", ".yml":
+		return formatYAML, nil
+	case ".toml":
+		return formatTOML, nil
+	case ".ini", ".cfg", ".gitconfig":
+		return formatINI, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s to merge %s, set format to %s, %s, %s or %s", installPath, item.Name, formatJSON, formatYAML, formatTOML, formatINI)
+}
+
+// checkStrategy rejects strategies that don't apply to item.
+func checkStrategy(item utils.ConfigureItem) error {
+	switch item.Strategy {
+	case "", strategyReplace:
+		return nil
+	case strategyMerge:
+	default:
+		return fmt.Errorf("unknown strategy %q for %s, expected %s or %s", item.Strategy, item.Name, strategyReplace, strategyMerge)
+	}
+	switch {
+	case len(item.ConfigureCommand) > 0:
+		return fmt.Errorf("strategy %s for %s needs config_url or source_path, not configure_command", strategyMerge, item.Name)
+	case item.Mode == modeSymlink:
+		return fmt.Errorf("strategy %s for %s can't be used with mode %s", strategyMerge, item.Name, modeSymlink)
+	}
+	return nil
+}
+
+// mergeInstalled merges content into the config installed at installPath,
+// reporting whether that changes it.
+func mergeInstalled(item utils.ConfigureItem, installPath string, content []byte) ([]byte, bool, error) {
+	format, err := mergeFormat(item, installPath)
+	if err != nil {
+		return nil, false, err
+	}
+	current, err := os.ReadFile(installPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read existing configuration: %v", err)
+	}
+	merged, err := mergeConfig(format, current, content)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to merge %s into %s: %v", item.Name, installPath, err)
+	}
+	return merged, !bytes.Equal(merged, current), nil
+}
+
+// mergeConfig deep-merges src, the downloaded config, into dst, the installed
+// one:
+//
+//   - where both set a key, src wins, except that two tables or objects are
+//     merged key by key
+//   - keys only dst sets are kept, so local additions survive
+//   - arrays, and TOML arrays of tables, are replaced as a whole
+//
+// dst is edited in place as far as the format allows, keeping its comments,
+// key order and indentation.
+func mergeConfig(format string, dst, src []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(dst)) == 0 {
+		return src, nil
+	}
+	switch format {
+	case formatJSON:
+		return mergeJSON(dst, src)
+	case formatYAML:
+		return mergeYAML(dst, src)
+	case formatTOML:
+		return mergeSections(dst, src, true)
+	case formatINI:
+		return mergeSections(dst, src, false)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// mergeYAML merges YAML documents through yaml.v3 nodes, which carry comments.
+func mergeYAML(dst, src []byte) ([]byte, error) {
+	var dstDoc, srcDoc yaml.Node
+	if err := yaml.Unmarshal(dst, &dstDoc); err != nil {
+		return nil, fmt.Errorf("failed to parse installed config: %v", err)
+	}
+	if err := yaml.Unmarshal(src, &srcDoc); err != nil {
+		return nil, fmt.Errorf("failed to parse new config: %v", err)
+	}
+	switch {
+	case len(srcDoc.Content) == 0:
+		return dst, nil
+	case len(dstDoc.Content) == 0:
+		return src, nil
+	}
+	if dstDoc.Content[0].Kind != yaml.MappingNode || srcDoc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("merge needs a YAML mapping at the top level")
+	}
+	// Encoding reformats the whole document, so only do it when the merge
+	// changes what the document says.
+	if !mergeYAMLNode(dstDoc.Content[0], srcDoc.Content[0]) {
+		return dst, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(yamlIndent(dst))
+	if err := encoder.Encode(&dstDoc); err != nil {
+		return nil, fmt.Errorf("failed to encode merged config: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode merged config: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeYAMLNode merges the mapping src into dst, reporting whether that
+// changed any value.
+func mergeYAMLNode(dst, src *yaml.Node) bool {
+	changed := false
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		j := yamlKeyIndex(dst, key.Value)
+		switch {
+		case j < 0:
+			dst.Content = append(dst.Content, key, value)
+			changed = true
+		case dst.Content[j+1].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			if mergeYAMLNode(dst.Content[j+1], value) {
+				changed = true
+			}
+		case sameYAML(dst.Content[j+1], value):
+		default:
+			changed = true
+			old := dst.Content[j+1]
+			if value.LineComment == "" {
+				value.LineComment = old.LineComment
+			}
+			if value.HeadComment == "" {
+				value.HeadComment = old.HeadComment
+			}
+			dst.Content[j+1] = value
+		}
+	}
+	return changed
+}
+
+// sameYAML reports whether two nodes decode to the same value, however they
+// are written.
+func sameYAML(a, b *yaml.Node) bool {
+	var av, bv interface{}
+	if a.Decode(&av) != nil || b.Decode(&bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// yamlIndent guesses the indentation of a YAML document from its least
+// indented nested line, so re-encoding it keeps the user's style.
+func yamlIndent(content []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		n := len(line) - len(trimmed)
+		if n == 0 || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indent == 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 2
+	}
+	return indent
+}
+
+func yamlKeyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// jsonValue is where a value sits in a JSON document. Objects also record
+// their members, which is all merging needs to patch the document as text.
+type jsonValue struct {
+	start, end int // Byte offsets, end exclusive
+	object     bool
+	members    []jsonMember
+}
+
+type jsonMember struct {
+	key              string
+	keyStart, keyEnd int
+	value            *jsonValue
+}
+
+func (v *jsonValue) member(key string) *jsonMember {
+	for i := range v.members {
+		if v.members[i].key == key {
+			return &v.members[i]
+		}
+	}
+	return nil
+}
+
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// mergeJSON patches changed and new members into dst's text rather than
+// re-encoding it, so the comments and trailing commas that editors like
+// VS Code allow in settings.json stay where they are.
+func mergeJSON(dst, src []byte) ([]byte, error) {
+	dstRoot, err := parseJSON(dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse installed config: %v", err)
+	}
+	srcRoot, err := parseJSON(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new config: %v", err)
+	}
+	if !dstRoot.object || !srcRoot.object {
+		return nil, fmt.Errorf("merge needs a JSON object at the top level")
+	}
+
+	unit := "  "
+	if len(dstRoot.members) > 0 {
+		if indent := lineIndent(dst, dstRoot.members[0].keyStart); indent != "" {
+			unit = indent
+		}
+	}
+	var edits []textEdit
+	if err := jsonEdits(dst, dstRoot, src, srcRoot, unit, &edits); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := append([]byte(nil), dst...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out, nil
+}
+
+func jsonEdits(dst []byte, d *jsonValue, src []byte, s *jsonValue, unit string, edits *[]textEdit) error {
+	var added []jsonMember
+	for _, m := range s.members {
+		existing := d.member(m.key)
+		switch {
+		case existing == nil:
+			added = append(added, m)
+		case existing.value.object && m.value.object:
+			if err := jsonEdits(dst, existing.value, src, m.value, unit, edits); err != nil {
+				return err
+			}
+		case sameJSON(dst, existing.value, src, m.value):
+			// Left as written, with its comments and layout.
+		default:
+			text, err := renderJSON(src, m.value, lineIndent(dst, existing.keyStart), unit)
+			if err != nil {
+				return err
+			}
+			*edits = append(*edits, textEdit{start: existing.value.start, end: existing.value.end, text: text})
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	var indent, closing string
+	at := d.start + 1
+	comma := len(d.members) > 0
+	if len(d.members) > 0 {
+		last := d.members[len(d.members)-1]
+		indent, at = lineIndent(dst, last.keyStart), last.value.end
+		// Keep a comment at the end of the last member's line with it.
+		j := skipBlanks(dst, at)
+		hasComma := j < len(dst) && dst[j] == ','
+		if hasComma {
+			j = skipBlanks(dst, j+1)
+		}
+		if bytes.HasPrefix(dst[j:], []byte("//")) {
+			if !hasComma {
+				*edits = append(*edits, textEdit{start: at, end: at, text: ","})
+			}
+			eol := bytes.IndexByte(dst[j:], '\n')
+			if eol < 0 {
+				eol = len(dst) - j
+			}
+			at, comma = j+eol, false
+		}
+	} else {
+		closing = "\n" + lineIndent(dst, d.end-1)
+		indent = lineIndent(dst, d.end-1) + unit
+	}
+	var b strings.Builder
+	for i, m := range added {
+		if i > 0 || comma {
+			b.WriteString(",")
+		}
+		text, err := renderJSON(src, m.value, indent, unit)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "\n%s%s: %s", indent, src[m.keyStart:m.keyEnd], text)
+	}
+	b.WriteString(closing)
+	*edits = append(*edits, textEdit{start: at, end: at, text: b.String()})
+	return nil
+}
+
+// sameJSON reports whether a value from dst and one from src decode to the
+// same value, however they are written.
+func sameJSON(dst []byte, d *jsonValue, src []byte, s *jsonValue) bool {
+	var dv, sv interface{}
+	if json.Unmarshal(stripJSONComments(dst[d.start:d.end]), &dv) != nil ||
+		json.Unmarshal(stripJSONComments(src[s.start:s.end]), &sv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(dv, sv)
+}
+
+// renderJSON formats a value from src for insertion at a line indented by
+// prefix. Comments inside it are dropped, as json.Indent can't keep them.
+func renderJSON(src []byte, v *jsonValue, prefix, unit string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, stripJSONComments(src[v.start:v.end]), prefix, unit); err != nil {
+		return "", fmt.Errorf("failed to format merged value: %v", err)
+	}
+	return buf.String(), nil
+}
+
+func skipBlanks(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// lineIndent returns the whitespace the line holding offset pos starts with.
+func lineIndent(data []byte, pos int) string {
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	end := start
+	for end < len(data) && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// stripJSONComments turns JSON with comments and trailing commas into plain JSON.
+func stripJSONComments(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := skipJSONString(data, i)
+			out = append(out, data[i:end]...)
+			i = end - 1
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			if end := bytes.Index(data[i+2:], []byte("*/")); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(data)
+			}
+		case c == ',':
+			if j := skipJSONSpace(data, i+1); j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// skipJSONString returns the offset just past the string starting at data[i].
+func skipJSONString(data []byte, i int) int {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(data)
+}
+
+// parseJSON parses JSON that may contain comments and trailing commas.
+func parseJSON(data []byte) (*jsonValue, error) {
+	p := &jsonParser{data: data}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if p.pos < len(data) {
+		return nil, p.errorf("unexpected %q after the document", data[p.pos])
+	}
+	return v, nil
+}
+
+type jsonParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *jsonParser) errorf(format string, args ...interface{}) error {
+	line := bytes.Count(p.data[:p.pos], []byte("\n")) + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skip moves past whitespace and comments.
+func (p *jsonParser) skip() {
+	p.pos = skipJSONSpace(p.data, p.pos)
+}
+
+// skipJSONSpace returns the offset of the first byte from i on that isn't
+// whitespace or part of a comment.
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) {
+		rest := data[i:]
+		switch {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n':
+			i++
+		case bytes.HasPrefix(rest, []byte("//")):
+			if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(data)
+			}
+		case bytes.HasPrefix(rest, []byte("/*")):
+			if end := bytes.Index(rest[2:], []byte("*/")); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(data)
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func (p *jsonParser) value() (*jsonValue, error) {
+	p.skip()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	v := &jsonValue{start: p.pos}
+	switch c := p.data[p.pos]; c {
+	case '{', '[':
+		v.object = c == '{'
+		if err := p.container(v, c); err != nil {
+			return nil, err
+		}
+	case '"':
+		p.pos = skipJSONString(p.data, p.pos)
+	default:
+		for p.pos < len(p.data) && !bytes.ContainsRune([]byte(",:]} \t\r\n/"), rune(p.data[p.pos])) {
+			p.pos++
+		}
+		if !json.Valid(p.data[v.start:p.pos]) {
+			p.pos = v.start
+			return nil, p.errorf("invalid value %q", p.data[v.start:min(v.start+20, len(p.data))])
+		}
+	}
+	v.end = p.pos
+	return v, nil
+}
+
+// container parses the object or array opening at p.pos.
+func (p *jsonParser) container(v *jsonValue, open byte) error {
+	closer := byte(']')
+	if open == '{' {
+		closer = '}'
+	}
+	p.pos++
+	for {
+		p.skip()
+		if p.pos < len(p.data) && p.data[p.pos] == closer {
+			p.pos++
+			return nil
+		}
+		if open == '{' {
+			if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+				return p.errorf("expected a string key")
+			}
+			m := jsonMember{keyStart: p.pos, keyEnd: skipJSONString(p.data, p.pos)}
+			if err := json.Unmarshal(p.data[m.keyStart:m.keyEnd], &m.key); err != nil {
+				return p.errorf("invalid key: %v", err)
+			}
+			p.pos = m.keyEnd
+			p.skip()
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return p.errorf("expected ':' after key %q", m.key)
+			}
+			p.pos++
+			value, err := p.value()
+			if err != nil {
+				return err
+			}
+			m.value = value
+			v.members = append(v.members, m)
+		} else if _, err := p.value(); err != nil {
+			return err
+		}
+		p.skip()
+		switch {
+		case p.pos >= len(p.data):
+			return p.errorf("unexpected end of input")
+		case p.data[p.pos] == ',':
+			p.pos++
+		case p.data[p.pos] != closer:
+			return p.errorf("expected ',' or '%c'", closer)
+		}
+	}
+}
+
+// iniSection is a [section] of a TOML or INI file with the lines that belong
+// to it; the lines before the first header form a section named "".
+type iniSection struct {
+	name  string
+	array bool     // A TOML [[array of tables]]
+	added bool     // Copied from the new config
+	lines []string // Starting with the header, except for the leading section
+}
+
+// iniEntry is a key and the lines [start, end) its value spans in a section.
+type iniEntry struct {
+	key        string
+	start, end int
+}
+
+// mergeSections merges TOML (toml set) or INI files line by line: new keys are
+// added after a section's last key, changed keys have their lines replaced and
+// new sections are appended. Lines mycli doesn't replace, comments included,
+// are kept as they are. TOML keys are matched regardless of quoting and
+// spacing, but a dotted key isn't moved into a [table] or back: a merge that
+// would set a key both ways is refused.
+func mergeSections(dst, src []byte, toml bool) ([]byte, error) {
+	dstSections := parseSections(string(dst), toml)
+	srcSections := parseSections(string(src), toml)
+	for _, s := range srcSections {
+		if s.array {
+			continue
+		}
+		d := findSection(dstSections, s.name)
+		if d == nil {
+			if s.name != "" {
+				s.added = true
+				dstSections = append(dstSections, s)
+			} else {
+				dstSections = append([]*iniSection{{}}, dstSections...)
+				mergeSection(dstSections[0], s, toml)
+			}
+			continue
+		}
+		mergeSection(d, s, toml)
+	}
+
+	// Arrays of tables are replaced as a whole.
+	replaced := make(map[string]bool)
+	for _, s := range srcSections {
+		if s.array {
+			replaced[s.name] = true
+		}
+	}
+	merged := dstSections[:0]
+	for _, d := range dstSections {
+		if !(d.array && replaced[d.name]) {
+			merged = append(merged, d)
+		}
+	}
+	for _, s := range srcSections {
+		if s.array {
+			s.added = true
+			merged = append(merged, s)
+		}
+	}
+
+	if toml {
+		if err := checkTOMLKeys(merged); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []string
+	for _, s := range merged {
+		if s.added && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+			out = append(out, "")
+		}
+		out = append(out, s.lines...)
+	}
+	text := strings.Join(out, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return []byte(text), nil
+}
+
+// checkTOMLKeys refuses TOML that sets a key twice, e.g. as a.b = 1 at the
+// top level and as b = 2 in an [a] table.
+func checkTOMLKeys(sections []*iniSection) error {
+	tables := make(map[string]bool)
+	dotted := make(map[string]string) // Tables a dotted key defines, to that key
+	values := make(map[string]bool)
+	var paths []string
+	for _, s := range sections {
+		// Keys of an array of tables belong to one of its elements.
+		if s.array {
+			continue
+		}
+		if s.name != "" {
+			if tables[s.name] {
+				return fmt.Errorf("table [%s] is defined twice", s.name)
+			}
+			tables[s.name] = true
+		}
+		for _, entry := range sectionEntries(s, true) {
+			path := entry.key
+			if s.name != "" {
+				path = s.name + "." + entry.key
+			}
+			if values[path] {
+				return fmt.Errorf("key %s is set twice", path)
+			}
+			values[path] = true
+			paths = append(paths, path)
+			parts := tomlKeyParts(path)
+			for i := len(tomlKeyParts(s.name)) + 1; i < len(parts); i++ {
+				dotted[strings.Join(parts[:i], ".")] = path
+			}
+		}
+	}
+	for _, s := range sections {
+		if key, ok := dotted[s.name]; ok && !s.array && s.name != "" {
+			return fmt.Errorf("table %s is set both by dotted key %s and by a [%s] table", s.name, key, s.name)
+		}
+	}
+	for _, path := range paths {
+		if key, ok := dotted[path]; ok {
+			return fmt.Errorf("key %s is set both as a value and as a table by dotted key %s", path, key)
+		}
+		if tables[path] {
+			return fmt.Errorf("key %s is set both as a value and as a [%s] table", path, path)
+		}
+	}
+	return nil
+}
+
+// tomlBareKey matches a TOML key part that needs no quotes.
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKeyParts splits a TOML key or table name at its dots, unquoting the
+// parts. Parts that need quotes are returned quoted, so joining them with
+// dots gives the key in a canonical form.
+func tomlKeyParts(key string) []string {
+	if strings.TrimSpace(key) == "" {
+		return nil
+	}
+	var parts []string
+	for rest, more := key, true; more; {
+		var part string
+		part, rest, more = cutOutsideQuotes(rest, '.')
+		part = strings.TrimSpace(part)
+		switch {
+		case len(part) >= 2 && part[0] == '\'' && part[len(part)-1] == '\'':
+			part = part[1 : len(part)-1]
+		case len(part) >= 2 && part[0] == '"' && part[len(part)-1] == '"':
+			if unquoted, err := strconv.Unquote(part); err == nil {
+				part = unquoted
+			}
+		}
+		if !tomlBareKey.MatchString(part) {
+			part = strconv.Quote(part)
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// tomlKey returns key in the canonical form of tomlKeyParts.
+func tomlKey(key string) string {
+	return strings.Join(tomlKeyParts(key), ".")
+}
+
+func findSection(sections []*iniSection, name string) *iniSection {
+	for _, s := range sections {
+		if !s.array && s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// mergeSection sets every key of src in dst.
+func mergeSection(dst, src *iniSection, toml bool) {
+	srcEntries := sectionEntries(src, toml)
+	for i := 0; i < len(srcEntries); {
+		// A key may repeat in INI, e.g. git's multi-valued fetch, so all of
+		// its lines in src replace all of its lines in dst.
+		entries := sectionEntries(dst, toml)
+		indent := ""
+		if len(entries) > 0 {
+			first := dst.lines[entries[0].start]
+			indent = first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+		}
+		key := srcEntries[i].key
+		var lines []string
+		for ; i < len(srcEntries) && srcEntries[i].key == key; i++ {
+			lines = append(lines, reindent(src.lines[srcEntries[i].start:srcEntries[i].end], indent)...)
+		}
+
+		at := -1
+		for j := len(entries) - 1; j >= 0; j-- {
+			if entries[j].key == key {
+				dst.lines = append(dst.lines[:entries[j].start], dst.lines[entries[j].end:]...)
+				at = entries[j].start
+			}
+		}
+		if at < 0 {
+			at = sectionEnd(dst, entries)
+		}
+		dst.lines = append(dst.lines[:at], append(lines, dst.lines[at:]...)...)
+	}
+}
+
+// sectionEnd is where a new key goes: after the last key, or else after the
+// header and any comments that follow it.
+func sectionEnd(s *iniSection, entries []iniEntry) int {
+	if len(entries) > 0 {
+		return entries[len(entries)-1].end
+	}
+	end := len(s.lines)
+	for end > 0 && strings.TrimSpace(s.lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+// reindent gives the first line of an entry the indentation of the section
+// it's moved into.
+func reindent(lines []string, indent string) []string {
+	out := append([]string(nil), lines...)
+	if len(out) > 0 {
+		out[0] = indent + strings.TrimLeft(out[0], " \t")
+	}
+	return out
+}
+
+func parseSections(content string, toml bool) []*iniSection {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	sections := []*iniSection{{}}
+	current := sections[0]
+	open := ""
+	for _, line := range lines {
+		if open == "" {
+			if name, array, ok := sectionHeader(line, toml); ok {
+				current = &iniSection{name: name, array: array}
+				sections = append(sections, current)
+			} else if toml {
+				if _, value, ok := splitEntry(line, toml); ok {
+					open = tomlOpen("", value)
+				}
+			}
+		} else {
+			open = tomlOpen(open, line)
+		}
+		current.lines = append(current.lines, line)
+	}
+	if len(sections[0].lines) == 0 {
+		sections = sections[1:]
+	}
+	return sections
+}
+
+func sectionEntries(s *iniSection, toml bool) []iniEntry {
+	var entries []iniEntry
+	i := 0
+	if s.name != "" {
+		i = 1
+	}
+	for ; i < len(s.lines); i++ {
+		key, value, ok := splitEntry(s.lines[i], toml)
+		if !ok {
+			continue
+		}
+		entry := iniEntry{key: key, start: i}
+		if toml {
+			for open := tomlOpen("", value); open != "" && i+1 < len(s.lines); open = tomlOpen(open, s.lines[i]) {
+				i++
+			}
+		}
+		entry.end = i + 1
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// sectionHeader parses a [section] line. INI section names are matched
+// case-insensitively, except for git-style "subsections".
+func sectionHeader(line string, toml bool) (string, bool, bool) {
+	trimmed := strings.TrimSpace(stripLineComment(line, toml))
+	if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+		return "", false, false
+	}
+	if toml && strings.HasPrefix(trimmed, "[[") && strings.HasSuffix(trimmed, "]]") {
+		return tomlKey(trimmed[2 : len(trimmed)-2]), true, true
+	}
+	name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+	if toml {
+		name = tomlKey(name)
+	} else {
+		section, sub, hasSub := strings.Cut(name, " ")
+		name = strings.ToLower(section)
+		if hasSub {
+			name += " " + strings.TrimSpace(sub)
+		}
+	}
+	return name, false, true
+}
+
+// splitEntry parses a key = value line. INI keys are case-insensitive and may
+// have no value.
+func splitEntry(line string, toml bool) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' || trimmed[0] == '[' {
+		return "", "", false
+	}
+	key, value, found := cutOutsideQuotes(trimmed, '=')
+	if !found && toml {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if toml {
+		key = tomlKey(key)
+	} else {
+		key = strings.ToLower(key)
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+func cutOutsideQuotes(s string, sep byte) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+func stripLineComment(line string, toml bool) string {
+	comments := "#"
+	if !toml {
+		comments = "#;"
+	}
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.IndexByte(comments, c) >= 0:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlOpen tracks whether a TOML value continues past text. state is what's
+// still open: a multi-line string delimiter followed by nothing, or a stack of
+// brackets; "" means the value is complete.
+func tomlOpen(state, text string) string {
+	for i := 0; i < len(text); i++ {
+		if strings.HasPrefix(state, `"""`) || strings.HasPrefix(state, "'''") {
+			delim := state[:3]
+			end := strings.Index(text[i:], delim)
+			if end < 0 {
+				return state
+			}
+			i += end + 2
+			state = state[3:]
+			continue
+		}
+		switch c := text[i]; c {
+		case '#':
+			return state
+		case '"', '\'':
+			if strings.HasPrefix(text[i:], strings.Repeat(string(c), 3)) {
+				state = strings.Repeat(string(c), 3) + state
+				i += 2
+				continue
+			}
+			for i++; i < len(text) && text[i] != c; i++ {
+				if c == '"' && text[i] == '\\' {
+					i++
+				}
+			}
+		case '[', '{':
+			state = string(c) + state
+		case ']', '}':
+			if state != "" {
+				state = state[1:]
+			}
+		}
+	}
+	return state
+}
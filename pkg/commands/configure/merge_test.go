@@ -0,0 +1,374 @@
+package configure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		dst    string
+		src    string
+		want   string
+	}{
+		{
+			name:   "JSON keeps comments and local keys",
+			format: formatJSON,
+			dst: `{
+    // Personal font
+    "editor.fontSize": 14,
+    "editor.rulers": [80],
+    "[go]": {
+        "editor.tabSize": 4, // tabs really
+    },
+}
+`,
+			src: `{"editor.rulers": [100, 120], "[go]": {"editor.formatOnSave": true}, "files.trimTrailingWhitespace": true}`,
+			want: `{
+    // Personal font
+    "editor.fontSize": 14,
+    "editor.rulers": [
+        100,
+        120
+    ],
+    "[go]": {
+        "editor.tabSize": 4, // tabs really
+        "editor.formatOnSave": true
+    },
+    "files.trimTrailingWhitespace": true,
+}
+`,
+		},
+		{
+			name:   "JSON into an empty object",
+			format: formatJSON,
+			dst:    "{\n  \"a\": {}\n}\n",
+			src:    `{"a": {"b": 1}}`,
+			want:   "{\n  \"a\": {\n    \"b\": 1\n  }\n}\n",
+		},
+		{
+			name:   "Empty installed file",
+			format: formatJSON,
+			dst:    "\n",
+			src:    `{"a": 1}`,
+			want:   `{"a": 1}`,
+		},
+		{
+			name:   "JSON already merged is left as written",
+			format: formatJSON,
+			dst:    "{\n  // mine\n  \"editor.rulers\": [80, /* wide */ 120],\n  \"editor.fontSize\": 14,\n}\n",
+			src:    "{\"editor.rulers\": [80, 120]}",
+			want:   "{\n  // mine\n  \"editor.rulers\": [80, /* wide */ 120],\n  \"editor.fontSize\": 14,\n}\n",
+		},
+		{
+			name:   "YAML already merged is left as written",
+			format: formatYAML,
+			dst:    "editor:\n    tab_size: 2   # aligned\n    wrap: 'yes'\nplugins: [a, b]\n",
+			src:    "editor:\n  wrap: \"yes\"\nplugins:\n  - a\n  - b\n",
+			want:   "editor:\n    tab_size: 2   # aligned\n    wrap: 'yes'\nplugins: [a, b]\n",
+		},
+		{
+			name:   "YAML keeps its indentation",
+			format: formatYAML,
+			dst:    "editor:\n    tab_size: 2\n",
+			src:    "editor:\n  wrap: true\n",
+			want:   "editor:\n    tab_size: 2\n    wrap: true\n",
+		},
+		{
+			name:   "YAML",
+			format: formatYAML,
+			dst: `# My settings
+theme: dark # the only choice
+editor:
+  tab_size: 2
+plugins:
+  - a
+`,
+			src: `editor:
+  wrap: true
+theme: light
+plugins:
+  - b
+`,
+			want: `# My settings
+theme: light # the only choice
+editor:
+  tab_size: 2
+  wrap: true
+plugins:
+  - b
+`,
+		},
+		{
+			name:   "TOML",
+			format: formatTOML,
+			dst: `# starship
+add_newline = false
+
+[character]
+success_symbol = "[>](green)" # mine
+
+[[battery.display]]
+threshold = 10
+
+[git_branch]
+symbol = "b "
+`,
+			src: `command_timeout = 1000
+
+[character]
+error_symbol = "[x](red)"
+success_symbol = "[➜](bold green)"
+
+[git_status]
+ignore_submodules = [
+  true,
+]
+
+[[battery.display]]
+threshold = 30
+`,
+			want: `# starship
+add_newline = false
+command_timeout = 1000
+
+[character]
+success_symbol = "[➜](bold green)"
+error_symbol = "[x](red)"
+
+[git_branch]
+symbol = "b "
+
+[git_status]
+ignore_submodules = [
+  true,
+]
+
+[[battery.display]]
+threshold = 30
+`,
+		},
+		{
+			name:   "INI",
+			format: formatINI,
+			dst: `[user]
+	name = Jane
+	email = jane@home.example
+[Core]
+	editor = vim
+[remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+`,
+			src: `[core]
+pager = delta
+editor = nvim
+[remote "origin"]
+fetch = +refs/heads/main:refs/remotes/origin/main
+fetch = +refs/tags/*:refs/tags/*
+[init]
+defaultBranch = main
+`,
+			want: `[user]
+	name = Jane
+	email = jane@home.example
+[Core]
+	editor = nvim
+	pager = delta
+[remote "origin"]
+	fetch = +refs/heads/main:refs/remotes/origin/main
+	fetch = +refs/tags/*:refs/tags/*
+
+[init]
+defaultBranch = main
+`,
+		},
+		{
+			name:   "TOML keys match regardless of quoting",
+			format: formatTOML,
+			dst:    "a . \"b\" = 1\n\n['tool.x']\nc = 1\n",
+			src:    "a.b = 2\n\n[\"tool.x\"]\nc = 2\n",
+			want:   "a.b = 2\n\n['tool.x']\nc = 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeConfig(tt.format, []byte(tt.dst), []byte(tt.src))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestMergeConfigErrors(t *testing.T) {
+	_, err := mergeConfig(formatJSON, []byte(`{"a": }`), []byte(`{}`))
+	assert.EqualError(t, err, `failed to parse installed config: line 1: invalid value "}"`)
+	_, err = mergeConfig(formatJSON, []byte(`[1]`), []byte(`{}`))
+	assert.EqualError(t, err, "merge needs a JSON object at the top level")
+	_, err = mergeConfig(formatYAML, []byte("- a\n"), []byte("a: 1\n"))
+	assert.EqualError(t, err, "merge needs a YAML mapping at the top level")
+}
+
+func TestMergeConfigTOMLDottedKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		dst     string
+		src     string
+		wantErr string
+	}{
+		{
+			name:    "Dotted key in dst, table in src",
+			dst:     "a.b = 1\n",
+			src:     "[a]\nb = 2\n",
+			wantErr: "key a.b is set twice",
+		},
+		{
+			name:    "Dotted key in dst, other key of its table in src",
+			dst:     "a.b = 1\n",
+			src:     "[a]\nc = 2\n",
+			wantErr: "table a is set both by dotted key a.b and by a [a] table",
+		},
+		{
+			name:    "Table in dst, dotted key in src",
+			dst:     "[a]\nb = 1\n",
+			src:     "a.b = 2\n",
+			wantErr: "key a.b is set twice",
+		},
+		{
+			name:    "Value in dst, dotted key in src",
+			dst:     "a = { b = 1 }\n",
+			src:     "a.c = 2\n",
+			wantErr: "key a is set both as a value and as a table by dotted key a.c",
+		},
+		{
+			name:    "Value in dst, table in src",
+			dst:     "a = 1\n",
+			src:     "[a]\nb = 2\n",
+			wantErr: "key a is set both as a value and as a [a] table",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mergeConfig(formatTOML, []byte(tt.dst), []byte(tt.src))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMergeFormat(t *testing.T) {
+	tests := []struct {
+		item    utils.ConfigureItem
+		path    string
+		want    string
+		wantErr string
+	}{
+		{path: "/h/.config/Code/User/settings.json", want: formatJSON},
+		{path: "/h/.config/starship.toml", want: formatTOML},
+		{path: "/h/.gitconfig", want: formatINI},
+		{path: "/h/.config/app/config.yml", want: formatYAML},
+		{item: utils.ConfigureItem{Format: formatINI}, path: "/h/.config/git/config", want: formatINI},
+		{item: utils.ConfigureItem{Name: "git"}, path: "/h/.config/git/config", wantErr: "cannot tell the format of /h/.config/git/config to merge git, set format to json, yaml, toml or ini"},
+		{item: utils.ConfigureItem{Name: "git", Format: "xml"}, path: "/h/a.xml", wantErr: `unknown format "xml" for git, expected json, yaml, toml or ini`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := mergeFormat(tt.item, tt.path)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigureMerge(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	source := filepath.Join(home, "dotfiles", "settings.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(source), 0755))
+	require.NoError(t, os.WriteFile(source, []byte(`{"editor.rulers": [100]}`), 0644))
+	installPath := filepath.Join(home, "settings.json")
+	require.NoError(t, os.WriteFile(installPath, []byte("{\n  \"editor.fontSize\": 14\n}\n"), 0644))
+
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "vscode", SourcePath: source, InstallPath: "~/settings.json", Strategy: strategyMerge},
+		},
+	}
+	ios, _, out, _ := iostreams.Test()
+	_, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `+  "editor.rulers": [`)
+
+	// Merging needs no --force, as local settings are kept.
+	_, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+	content, err := os.ReadFile(installPath)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"editor.fontSize\": 14,\n  \"editor.rulers\": [\n    100\n  ]\n}\n", string(content))
+
+	statuses, err := configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusInSync, statuses[0].Status)
+
+	require.NoError(t, os.WriteFile(installPath, []byte("{\n  \"editor.fontSize\": 16,\n  \"editor.rulers\": [80]\n}\n"), 0644))
+	statuses, err = configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusLocallyModified, statuses[0].Status)
+}
+
+func TestMergeConfigWithItself(t *testing.T) {
+	files := map[string]string{
+		formatJSON: "{\n  \"a\": [1, 2], // note\n  \"b\": {\"c\": true}\n}\n",
+		formatYAML: "a: [1, 2] # note\nb:\n    c: true\n",
+		formatTOML: "a = [1, 2] # note\n\n[b]\nc = true\n",
+		formatINI:  "[b]\n\tc = true\n",
+	}
+	for format, content := range files {
+		t.Run(format, func(t *testing.T) {
+			got, err := mergeConfig(format, []byte(content), []byte(content))
+			require.NoError(t, err)
+			assert.Equal(t, content, string(got))
+		})
+	}
+}
+
+func TestConfigureMergeYAMLUnchanged(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	source := filepath.Join(home, "dotfiles", "config.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(source), 0755))
+	require.NoError(t, os.WriteFile(source, []byte("editor:\n  wrap: true\n"), 0644))
+	installPath := filepath.Join(home, "config.yml")
+	installed := "# mine\neditor:\n    tab_size: 4\n    wrap: true\n"
+	require.NoError(t, os.WriteFile(installPath, []byte(installed), 0644))
+
+	item := utils.ConfigureItem{Name: "app", SourcePath: source, InstallPath: "~/config.yml", Strategy: strategyMerge}
+	upstream, err := os.ReadFile(source)
+	require.NoError(t, err)
+	_, changed, err := mergeInstalled(item, installPath, upstream)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	config := &utils.ToolConfig{Configure: []utils.ConfigureItem{item}}
+	statuses, err := configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusInSync, statuses[0].Status)
+
+	ios, _, _, _ := iostreams.Test()
+	_, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+	content, err := os.ReadFile(installPath)
+	require.NoError(t, err)
+	assert.Equal(t, installed, string(content))
+}
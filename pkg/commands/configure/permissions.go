@@ -0,0 +1,213 @@
+package configure
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+)
+
+// permissions are the parsed file_mode, dir_mode and owner of a configure
+// item. Zero modes and negative ids mean the field isn't set.
+type permissions struct {
+	fileMode os.FileMode
+	dirMode  os.FileMode
+	uid, gid int
+}
+
+func (p permissions) owned() bool {
+	return p.uid >= 0 || p.gid >= 0
+}
+
+// itemPermissions parses the permission fields of item.
+func itemPermissions(item utils.ConfigureItem) (permissions, error) {
+	p := permissions{uid: -1, gid: -1}
+	var err error
+	if p.fileMode, err = parseMode(item.Name, "file_mode", item.FileMode); err != nil {
+		return p, err
+	}
+	if p.dirMode, err = parseMode(item.Name, "dir_mode", item.DirMode); err != nil {
+		return p, err
+	}
+	if item.Owner != "" {
+		if p.uid, p.gid, err = lookupOwner(item.Owner); err != nil {
+			return p, fmt.Errorf("invalid owner %q for %s: %v", item.Owner, item.Name, err)
+		}
+	}
+	return p, nil
+}
+
+func parseMode(name, field, value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("invalid %s %q for %s, expected an octal mode such as 0600", field, value, name)
+	}
+	return os.FileMode(mode), nil
+}
+
+// lookupOwner resolves "user" or "user:group" to ids; either side may be numeric.
+func lookupOwner(owner string) (int, int, error) {
+	name, group, _ := strings.Cut(owner, ":")
+	uid, gid := -1, -1
+	if name != "" {
+		id := name
+		if _, err := strconv.Atoi(name); err != nil {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return 0, 0, err
+			}
+			id = u.Uid
+		}
+		uid, _ = strconv.Atoi(id)
+	}
+	if group != "" {
+		id := group
+		if _, err := strconv.Atoi(group); err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return 0, 0, err
+			}
+			id = g.Gid
+		}
+		gid, _ = strconv.Atoi(id)
+	}
+	return uid, gid, nil
+}
+
+// applyParentDir enforces dir_mode and owner on the directory holding a
+// single-file config, so that e.g. ~/.ssh ends up 0700. The home directory is
+// never changed.
+func applyParentDir(installPath string, p permissions) error {
+	dir := filepath.Dir(installPath)
+	if home, err := os.UserHomeDir(); err == nil && filepath.Clean(dir) == filepath.Clean(home) {
+		return nil
+	}
+	return p.applyDir(dir)
+}
+
+// applyFile enforces file_mode and owner on a file.
+func (p permissions) applyFile(path string) error {
+	if p.fileMode != 0 {
+		if err := os.Chmod(path, p.fileMode); err != nil {
+			return fmt.Errorf("failed to set mode of %s: %v", path, err)
+		}
+	}
+	if p.owned() {
+		if err := os.Lchown(path, p.uid, p.gid); err != nil {
+			return fmt.Errorf("failed to set owner of %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// applyDir enforces dir_mode and owner on a directory.
+func (p permissions) applyDir(path string) error {
+	if p.dirMode != 0 {
+		if err := os.Chmod(path, p.dirMode); err != nil {
+			return fmt.Errorf("failed to set mode of %s: %v", path, err)
+		}
+	}
+	if p.owned() {
+		if err := os.Lchown(path, p.uid, p.gid); err != nil {
+			return fmt.Errorf("failed to set owner of %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// applyTree enforces the permissions on everything under root. Symlinks are
+// left alone, as are nested .git directories.
+func (p permissions) applyTree(root string) error {
+	if p.fileMode == 0 && p.dirMode == 0 && !p.owned() {
+		return nil
+	}
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir() && info.Name() == ".git" && path != root:
+			return filepath.SkipDir
+		case info.IsDir():
+			return p.applyDir(path)
+		case info.Mode()&os.ModeSymlink != 0:
+			return nil
+		default:
+			return p.applyFile(path)
+		}
+	})
+}
+
+// permissionDrift describes the first file or directory under path whose mode
+// differs from file_mode or dir_mode, or whose owner differs from owner, or
+// returns "" when they all match.
+func permissionDrift(path string, p permissions) string {
+	if p.fileMode == 0 && p.dirMode == 0 && !p.owned() {
+		return ""
+	}
+	var drift string
+	_ = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		want := p.fileMode
+		if info.IsDir() {
+			if info.Name() == ".git" && file != path {
+				return filepath.SkipDir
+			}
+			want = p.dirMode
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			drift = fileDrift(file, info, want, p)
+		}
+		if drift != "" {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return drift
+}
+
+// fileDrift describes how the mode of path differs from want, if set, or else
+// how its owner differs from p's.
+func fileDrift(path string, info os.FileInfo, want os.FileMode, p permissions) string {
+	if drift := modeDrift(path, info, want); drift != "" {
+		return drift
+	}
+	return ownerDrift(path, info, p)
+}
+
+// modeDrift describes how the mode of path differs from want, if set.
+func modeDrift(path string, info os.FileInfo, want os.FileMode) string {
+	if want == 0 || info.Mode().Perm() == want {
+		return ""
+	}
+	return fmt.Sprintf("%s is mode %04o, expected %04o", path, info.Mode().Perm(), want)
+}
+
+// ownerDrift describes how the owner and group of path differ from the uid
+// and gid in p, where set.
+func ownerDrift(path string, info os.FileInfo, p permissions) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	var drift []string
+	if p.uid >= 0 && int(stat.Uid) != p.uid {
+		drift = append(drift, fmt.Sprintf("uid %d, expected %d", stat.Uid, p.uid))
+	}
+	if p.gid >= 0 && int(stat.Gid) != p.gid {
+		drift = append(drift, fmt.Sprintf("gid %d, expected %d", stat.Gid, p.gid))
+	}
+	if len(drift) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s has %s", path, strings.Join(drift, " and "))
+}
@@ -0,0 +1,180 @@
+package configure
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemPermissions(t *testing.T) {
+	uid, gid := strconv.Itoa(os.Getuid()), strconv.Itoa(os.Getgid())
+	tests := []struct {
+		name    string
+		item    utils.ConfigureItem
+		want    permissions
+		wantErr string
+	}{
+		{
+			name: "Unset",
+			item: utils.ConfigureItem{Name: "ssh"},
+			want: permissions{uid: -1, gid: -1},
+		},
+		{
+			name: "Modes",
+			item: utils.ConfigureItem{Name: "ssh", FileMode: "0600", DirMode: "700"},
+			want: permissions{fileMode: 0600, dirMode: 0700, uid: -1, gid: -1},
+		},
+		{
+			name: "Numeric owner and group",
+			item: utils.ConfigureItem{Name: "ssh", Owner: uid + ":" + gid},
+			want: permissions{uid: os.Getuid(), gid: os.Getgid()},
+		},
+		{
+			name:    "Not octal",
+			item:    utils.ConfigureItem{Name: "ssh", FileMode: "0644x"},
+			wantErr: `invalid file_mode "0644x" for ssh, expected an octal mode such as 0600`,
+		},
+		{
+			name:    "Out of range",
+			item:    utils.ConfigureItem{Name: "ssh", DirMode: "4755"},
+			wantErr: `invalid dir_mode "4755" for ssh, expected an octal mode such as 0600`,
+		},
+		{
+			name:    "Unknown user",
+			item:    utils.ConfigureItem{Name: "ssh", Owner: "mycli-no-such-user"},
+			wantErr: `invalid owner "mycli-no-such-user" for ssh`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := itemPermissions(tt.item)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigurePermissions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	source := filepath.Join(home, "dotfiles", "ssh_config")
+	tree := filepath.Join(home, "dotfiles", "gnupg")
+	require.NoError(t, os.MkdirAll(filepath.Join(tree, "private-keys"), 0755))
+	require.NoError(t, os.WriteFile(source, []byte("Host *\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tree, "private-keys", "key"), []byte("secret\n"), 0644))
+	// An existing, too open ~/.ssh is tightened as well.
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".ssh"), 0755))
+
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "ssh", SourcePath: source, InstallPath: "~/.ssh/config", FileMode: "0600", DirMode: "0700"},
+			{Name: "gnupg", SourcePath: tree, InstallPath: "~/.gnupg", FileMode: "0600", DirMode: "0700"},
+			{Name: "zsh", SourcePath: source, InstallPath: "~/.zshrc"},
+		},
+	}
+	// A file without file_mode keeps the mode it already had.
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".zshrc"), []byte("old\n"), 0640))
+
+	require.NoError(t, os.Chmod(home, 0755))
+
+	ios, _, _, _ := iostreams.Test()
+	_, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{Force: true})
+	require.NoError(t, err)
+
+	modes := map[string]os.FileMode{
+		".ssh":                    0700,
+		".ssh/config":             0600,
+		".gnupg":                  0700,
+		".gnupg/private-keys":     0700,
+		".gnupg/private-keys/key": 0600,
+		".zshrc":                  0640,
+	}
+	for path, want := range modes {
+		info, err := os.Stat(filepath.Join(home, path))
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Mode().Perm(), path)
+	}
+	info, err := os.Stat(home)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm(), "the home directory should be left alone")
+
+	statuses, err := configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	for _, st := range statuses {
+		assert.Equal(t, statusInSync, st.Status, st.Name)
+	}
+
+	require.NoError(t, os.Chmod(filepath.Join(home, ".ssh", "config"), 0644))
+	require.NoError(t, os.Chmod(filepath.Join(home, ".gnupg", "private-keys"), 0755))
+	statuses, err = configStatuses(context.Background(), config, []string{"ssh", "gnupg"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusPermissionDrift, statuses[0].Status)
+	assert.Equal(t, filepath.Join(home, ".ssh", "config")+" is mode 0644, expected 0600", statuses[0].Detail)
+	assert.Equal(t, statusPermissionDrift, statuses[1].Status)
+	assert.Equal(t, filepath.Join(home, ".gnupg", "private-keys")+" is mode 0755, expected 0700", statuses[1].Detail)
+}
+
+func TestOwnerDrift(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte("x\n"), 0600))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	uid, gid := os.Getuid(), os.Getgid()
+
+	tests := []struct {
+		name string
+		p    permissions
+		want string
+	}{
+		{"No owner", permissions{uid: -1, gid: -1}, ""},
+		{"Same owner", permissions{uid: uid, gid: gid}, ""},
+		{"Other user", permissions{uid: uid + 1, gid: -1}, fmt.Sprintf("%s has uid %d, expected %d", path, uid, uid+1)},
+		{"Other user and group", permissions{uid: uid + 1, gid: gid + 1}, fmt.Sprintf("%s has uid %d, expected %d and gid %d, expected %d", path, uid, uid+1, gid, gid+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ownerDrift(path, info, tt.p))
+		})
+	}
+}
+
+func TestConfigureOwnerStatus(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	source := filepath.Join(home, "dotfiles", "netrc")
+	require.NoError(t, os.MkdirAll(filepath.Dir(source), 0755))
+	require.NoError(t, os.WriteFile(source, []byte("machine example.com\n"), 0600))
+
+	uid := os.Getuid()
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "netrc", SourcePath: source, InstallPath: "~/.netrc", Owner: strconv.Itoa(uid)},
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	_, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+
+	statuses, err := configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusInSync, statuses[0].Status)
+
+	// Owned by someone else than the config asks for, as after a sudo run.
+	config.Configure[0].Owner = strconv.Itoa(uid + 1)
+	statuses, err = configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusPermissionDrift, statuses[0].Status)
+	assert.Equal(t, fmt.Sprintf("%s has uid %d, expected %d", filepath.Join(home, ".netrc"), uid, uid+1), statuses[0].Detail)
+}
@@ -0,0 +1,192 @@
+package configure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+)
+
+var execCommandContext = exec.CommandContext
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// repoCheckout is a repository synced during a run, keyed by repo and ref so
+// items sharing a checkout only fetch it once.
+type repoCheckout struct {
+	dir    string
+	commit string
+}
+
+func reposDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(home, ".mycli", "repos"), nil
+}
+
+// repoCacheDir returns the checkout directory for repo at ref. Each ref gets
+// its own checkout so items pinned to different refs don't fight over one tree.
+func repoCacheDir(repo, ref string) (string, error) {
+	root, err := reposDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(repo, ".git")
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	name = strings.Trim(unsafePathChars.ReplaceAllString(name, "_"), "_")
+	return filepath.Join(root, name+"@"+unsafePathChars.ReplaceAllString(ref, "_")), nil
+}
+
+// syncRepo clones repo into the cache, or fetches it when already cached, and
+// checks out ref. Offline, the cached clone is used as is. A dry run changes
+// nothing, not even the checkout, and takes the commit checked out last. It
+// returns the checkout directory and the commit checked out.
+func syncRepo(ctx context.Context, repo, ref string, dryRun bool) (string, string, error) {
+	dir, err := repoCacheDir(repo, ref)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = os.Stat(filepath.Join(dir, ".git"))
+	cloned := err == nil
+	switch {
+	case utils.IsOffline(ctx) || dryRun:
+		if !cloned {
+			return "", "", fmt.Errorf("%s is %w, run once without --offline to clone it", utils.RedactURL(repo), utils.ErrNotCached)
+		}
+		if dryRun {
+			commit, err := git(ctx, dir, "rev-parse", "HEAD")
+			if err != nil {
+				return "", "", err
+			}
+			return dir, commit, nil
+		}
+	case !cloned:
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", "", fmt.Errorf("failed to create repository cache: %v", err)
+		}
+		fmt.Printf("Cloning %s\n", utils.RedactURL(repo))
+		if _, err := gitWithEnv(ctx, "", gitAuthEnv(ctx, repo), "clone", "--quiet", repo, dir); err != nil {
+			return "", "", err
+		}
+	default:
+		fmt.Printf("Fetching %s\n", utils.RedactURL(repo))
+		if _, err := gitWithEnv(ctx, dir, gitAuthEnv(ctx, repo), "fetch", "--quiet", "--tags", "--force", "origin"); err != nil {
+			return "", "", err
+		}
+	}
+
+	commit, err := resolveRef(ctx, dir, ref)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := git(ctx, dir, "checkout", "--quiet", "--force", "--detach", commit); err != nil {
+		return "", "", err
+	}
+	return dir, commit, nil
+}
+
+// resolveRef finds the commit for ref, preferring the remote branch of that
+// name over a local branch, tag or commit.
+func resolveRef(ctx context.Context, dir, ref string) (string, error) {
+	candidates := []string{"origin/HEAD"}
+	if ref != "" {
+		candidates = []string{"origin/" + ref, ref}
+	}
+	for _, candidate := range candidates {
+		if commit, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+	if ref == "" {
+		ref = "the default branch"
+	}
+	return "", fmt.Errorf("ref %s not found in %s", ref, dir)
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	return gitWithEnv(ctx, dir, nil, args...)
+}
+
+func gitWithEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := execCommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.New(utils.Redact(fmt.Sprintf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(string(output)))))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitAuthEnv passes the credentials for an https repo's host to git as an
+// extra header, through the environment so they never show up in the command
+// line or in the clone's config. GitHub takes tokens as the password of any
+// user, so bearer tokens are sent with basic auth as x-access-token.
+func gitAuthEnv(ctx context.Context, repo string) []string {
+	u, err := url.Parse(repo)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.User != nil {
+		return nil
+	}
+	creds, ok := utils.CredentialsFor(ctx, u.Hostname())
+	if !ok {
+		return nil
+	}
+	if creds.Username == "" {
+		creds.Username = "x-access-token"
+	}
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		fmt.Sprintf("GIT_CONFIG_KEY_0=http.%s://%s/.extraHeader", u.Scheme, u.Host),
+		"GIT_CONFIG_VALUE_0=Authorization: " + creds.Header(),
+	}
+}
+
+// resolveRepoSource syncs the item's repo and points its source_path at
+// repo_path within the checkout, so it installs like any other local source.
+// It returns the updated item and the commit it was taken from.
+func resolveRepoSource(ctx context.Context, item utils.ConfigureItem, checkouts map[string]repoCheckout, dryRun bool) (utils.ConfigureItem, string, error) {
+	key := item.Repo + "@" + item.Ref
+	checkout, ok := checkouts[key]
+	if !ok {
+		dir, commit, err := syncRepo(ctx, item.Repo, item.Ref, dryRun)
+		if err != nil {
+			return item, "", err
+		}
+		checkout = repoCheckout{dir: dir, commit: commit}
+		if checkouts != nil {
+			checkouts[key] = checkout
+		}
+	}
+
+	source := filepath.Join(checkout.dir, filepath.FromSlash(item.RepoPath))
+	if !within(checkout.dir, source) {
+		return item, "", fmt.Errorf("repo_path %s is outside the repository", item.RepoPath)
+	}
+	if _, err := os.Stat(source); err != nil {
+		return item, "", fmt.Errorf("%s not found in %s at %s", item.RepoPath, item.Repo, shortCommit(checkout.commit))
+	}
+	item.SourcePath = source
+	return item, checkout.commit, nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,116 @@
+package configure
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// newBackupsCmd lists the backups taken before configs were overwritten.
+//
+// Usage:
+//
+//	mycli configure backups [name]
+func newBackupsCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "backups [name]",
+		Short: "List backups of overwritten configs",
+		Args:  cobra.MaximumNArgs(1),
+		Annotations: map[string]string{
+			"group": "configure",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, _ := tracer.StartSpanFromContext(cmd.Context(), "configure_backups")
+			defer span.Finish()
+
+			var name string
+			if len(args) == 1 {
+				name = args[0]
+			}
+			backups, err := listBackups(name)
+			if err != nil {
+				return err
+			}
+			if len(backups) == 0 {
+				fmt.Fprintln(iostream.Out, "No backups found.")
+				return nil
+			}
+
+			table := tablewriter.NewWriter(iostream.Out)
+			table.SetHeader([]string{"Name", "Backup", "Created", "Path"})
+			table.SetHeaderColor(
+				tablewriter.Colors{tablewriter.FgGreenColor},
+				tablewriter.Colors{tablewriter.FgGreenColor},
+				tablewriter.Colors{tablewriter.FgGreenColor},
+				tablewriter.Colors{tablewriter.FgGreenColor},
+			)
+			for _, b := range backups {
+				table.Append([]string{b.Name, b.ID, b.Created.Local().Format(time.DateTime), b.Path})
+			}
+			table.Render()
+			return nil
+		},
+	}
+}
+
+// newRestoreCmd restores a config from its backups.
+//
+// Usage:
+//
+//	mycli configure restore <name> [--at time]
+//
+// Flags:
+//
+//	-c, --config string   Configuration file whose backups section applies to
+//	                      the backup of the current file, if it exists (default "config.yaml")
+//	--at string           Backup ID, or a time to restore the newest backup taken at or before it
+func newRestoreCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cs := iostream.ColorScheme()
+	var configFile string
+	var at string
+
+	cmd := &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Restore a config from its backups",
+		Args:  cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			"group": "configure",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, _ := tracer.StartSpanFromContext(cmd.Context(), "configure_restore")
+			defer span.Finish()
+
+			b, err := findBackup(args[0], at)
+			if err != nil {
+				return err
+			}
+			var policy utils.BackupPolicy
+			if _, err := os.Stat(configFile); err == nil {
+				config, err := utils.LoadToolsConfig(configFile)
+				if err != nil {
+					return fmt.Errorf("failed to load %s: %v", configFile, err)
+				}
+				policy = config.Backups
+			}
+			previous, err := restoreBackup(b, policy)
+			if err != nil {
+				return err
+			}
+			if previous != "" {
+				fmt.Fprintf(iostream.Out, "Backed up current %s to %s\n", b.Path, previous)
+			}
+			fmt.Fprintf(iostream.Out, cs.Green("Restored %s from backup %s\n"), b.Path, b.ID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	cmd.Flags().StringVar(&at, "at", "", "Backup ID or time (RFC3339 or YYYY-MM-DD[ HH:MM]) to restore from")
+	return cmd
+}
@@ -0,0 +1,76 @@
+package configure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupsAndRestoreCmd(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".zshrc")
+
+	ios, _, stdout, _ := iostreams.Test()
+	cmd := newBackupsCmd(ios)
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "No backups found.")
+
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+	_, err := backupConfig("zsh", path, utils.BackupPolicy{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("new"), 0644))
+
+	stdout.Reset()
+	cmd = newBackupsCmd(ios)
+	cmd.SetArgs([]string{"zsh"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), path)
+
+	stdout.Reset()
+	cmd = newRestoreCmd(ios)
+	cmd.SetArgs([]string{"zsh"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "Restored "+path)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+
+	cmd = newRestoreCmd(ios)
+	cmd.SetArgs([]string{"vim"})
+	assert.EqualError(t, cmd.Execute(), "no backups found for vim")
+}
+
+func TestRestoreCmdUsesConfiguredRetention(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".zshrc")
+	configFile := filepath.Join(home, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("backups:\n  keep: 2\n"), 0644))
+
+	for _, content := range []string{"first", "second"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := backupConfig("zsh", path, utils.BackupPolicy{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(path, []byte("current"), 0644))
+
+	ios, _, _, _ := iostreams.Test()
+	cmd := newRestoreCmd(ios)
+	cmd.SetArgs([]string{"zsh", "--config", configFile})
+	require.NoError(t, cmd.Execute())
+
+	// Backing up the current file pushed out the oldest backup.
+	backups, err := listBackups("zsh")
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+}
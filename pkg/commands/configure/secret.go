@@ -0,0 +1,112 @@
+package configure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+)
+
+// builtinSecretProviders resolve the argument of a "<provider>:<arg>" secret
+// reference. Providers from the secret_providers section of the config are
+// commands run through the shell with the argument as $1, e.g.
+//
+//	secret_providers:
+//	  pass: pass show "$1"
+//	  op: op read "$1"
+var builtinSecretProviders = map[string]func(ctx context.Context, arg string) (string, error){
+	"env": func(ctx context.Context, arg string) (string, error) {
+		value, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", arg)
+		}
+		return value, nil
+	},
+	"file": func(ctx context.Context, arg string) (string, error) {
+		content, err := os.ReadFile(expandTilde(arg))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %v", arg, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	},
+	"cmd": func(ctx context.Context, arg string) (string, error) {
+		return runSecretCommand(ctx, arg)
+	},
+}
+
+// secretResolver resolves the secret references of a configure run. Every
+// value it returns is registered with utils.Redact, so it is masked wherever
+// output is redacted, and resolved only once per run.
+type secretResolver struct {
+	ctx       context.Context
+	providers map[string]string
+	resolved  map[string]string
+}
+
+func newSecretResolver(ctx context.Context, providers map[string]string) *secretResolver {
+	return &secretResolver{ctx: ctx, providers: providers, resolved: make(map[string]string)}
+}
+
+// resolve returns the secret a reference such as "env:NPM_TOKEN" points to.
+func (r *secretResolver) resolve(ref string) (string, error) {
+	if value, ok := r.resolved[ref]; ok {
+		return value, nil
+	}
+	name, arg, ok := strings.Cut(ref, ":")
+	if !ok || arg == "" {
+		return "", fmt.Errorf("invalid secret reference %q, expected <provider>:<name>", ref)
+	}
+
+	var value string
+	var err error
+	if provider, ok := builtinSecretProviders[name]; ok {
+		value, err = provider(r.ctx, arg)
+	} else if command, ok := r.providers[name]; ok {
+		value, err = runSecretCommand(r.ctx, command, arg)
+	} else {
+		return "", fmt.Errorf("unknown secret provider %q, expected one of %s", name, strings.Join(r.providerNames(), ", "))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %v", ref, err)
+	}
+
+	utils.RegisterSecret(value)
+	for _, line := range strings.Split(value, "\n") {
+		utils.RegisterSecret(strings.TrimSpace(line))
+	}
+	r.resolved[ref] = value
+	return value, nil
+}
+
+func (r *secretResolver) providerNames() []string {
+	names := make([]string, 0, len(builtinSecretProviders)+len(r.providers))
+	for name := range builtinSecretProviders {
+		names = append(names, name)
+	}
+	for name := range r.providers {
+		if _, ok := builtinSecretProviders[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runSecretCommand runs command with sh, passing args as $1..., and returns
+// its output without the trailing newline. Stderr ends up in the error only,
+// redacted.
+func runSecretCommand(ctx context.Context, command string, args ...string) (string, error) {
+	cmd := execCommandContext(ctx, "sh", append([]string{"-c", command, "sh"}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.New(utils.Redact(fmt.Sprintf("%v: %s", err, strings.TrimSpace(stderr.String()))))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
@@ -0,0 +1,84 @@
+package configure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretResolver(t *testing.T) {
+	t.Setenv("MYCLI_TEST_SECRET", "env-secret-value")
+	secretFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret-value\n"), 0600))
+
+	resolver := newSecretResolver(context.Background(), map[string]string{
+		"echo": `printf 'provided-%s' "$1"`,
+		"fail": `echo "no such entry" >&2; exit 1`,
+	})
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "env:MYCLI_TEST_SECRET", want: "env-secret-value"},
+		{ref: "env:MYCLI_TEST_UNSET", wantErr: "failed to resolve secret env:MYCLI_TEST_UNSET: environment variable MYCLI_TEST_UNSET is not set"},
+		{ref: "file:" + secretFile, want: "file-secret-value"},
+		{ref: "cmd:printf cmd-secret-value", want: "cmd-secret-value"},
+		{ref: "echo:npm/token", want: "provided-npm/token"},
+		{ref: "fail:npm", wantErr: "failed to resolve secret fail:npm: exit status 1: no such entry"},
+		{ref: "vault:npm", wantErr: `unknown secret provider "vault", expected one of cmd, echo, env, fail, file`},
+		{ref: "NPM_TOKEN", wantErr: `invalid secret reference "NPM_TOKEN", expected <provider>:<name>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := resolver.resolve(tt.ref)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "token ****", utils.Redact("token "+got), "resolved secrets should be redacted")
+		})
+	}
+}
+
+func TestRenderTemplateSecret(t *testing.T) {
+	t.Setenv("MYCLI_TEST_NPM_TOKEN", "npm-secret-token")
+	out, err := renderTemplate("npmrc", []byte(`//registry.npmjs.org/:_authToken={{ secret "env:MYCLI_TEST_NPM_TOKEN" }}`), map[string]string{}, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "//registry.npmjs.org/:_authToken=npm-secret-token", string(out))
+}
+
+func TestConfigureDryRunMasksSecrets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("MYCLI_TEST_NPM_TOKEN", "npm-dry-run-token")
+	source := filepath.Join(home, "npmrc.tmpl")
+	require.NoError(t, os.WriteFile(source, []byte(`_authToken={{ secret "env:MYCLI_TEST_NPM_TOKEN" }}`+"\n"), 0644))
+
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "npm", SourcePath: source, InstallPath: "~/.npmrc", Template: true},
+			{Name: "tmux", ConfigureCommand: []string{"tmux source ~/.tmux.conf"}, InstallPath: "~/.tmux.conf"},
+		},
+	}
+	ios, _, out, _ := iostreams.Test()
+	stats, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "dry run", stats[0].Detail)
+
+	assert.Contains(t, out.String(), "+_authToken=****")
+	assert.NotContains(t, out.String(), "npm-dry-run-token")
+	assert.Contains(t, out.String(), "Dry run complete, nothing was changed.")
+	_, err = os.Stat(filepath.Join(home, ".npmrc"))
+	assert.True(t, os.IsNotExist(err), "dry run should not write the config")
+}
@@ -0,0 +1,253 @@
+package configure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/AlecAivazis/survey/v2"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// sshResult is what configureSSH changed, or would change in a dry run.
+type sshResult struct {
+	generated int
+	hosts     int
+}
+
+func sshDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(home, ".ssh"), nil
+}
+
+// configureSSH generates the section's keys that don't exist yet and writes
+// its host entries to ~/.ssh/config, each in its own managed block. ~/.ssh
+// is kept at 0700 and the config and private keys at 0600, as ssh refuses
+// keys others can read.
+func configureSSH(ctx context.Context, cfg *utils.SSHConfig, opts ConfigureOptions) (sshResult, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "configure_ssh")
+	defer span.Finish()
+
+	var res sshResult
+	dir, err := sshDir()
+	if err != nil {
+		return res, err
+	}
+	if !opts.DryRun {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return res, fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+		if err := os.Chmod(dir, 0700); err != nil {
+			return res, fmt.Errorf("failed to set mode of %s: %v", dir, err)
+		}
+	}
+
+	for _, key := range cfg.Keys {
+		generated, err := ensureSSHKey(dir, key, opts)
+		if err != nil {
+			return res, err
+		}
+		if generated {
+			res.generated++
+		}
+	}
+
+	configPath := filepath.Join(dir, "config")
+	for _, host := range cfg.Hosts {
+		changed, err := setSSHHost(configPath, host, opts.DryRun)
+		if err != nil {
+			return res, err
+		}
+		if changed {
+			res.hosts++
+		}
+	}
+	if _, err := os.Stat(configPath); err == nil && !opts.DryRun {
+		if err := os.Chmod(configPath, 0600); err != nil {
+			return res, fmt.Errorf("failed to set mode of %s: %v", configPath, err)
+		}
+	}
+	return res, nil
+}
+
+// ensureSSHKey generates key unless it already exists, reporting whether it
+// did. The passphrase is prompted for when interactive.
+func ensureSSHKey(dir string, key utils.SSHKey, opts ConfigureOptions) (bool, error) {
+	if key.Name == "" || strings.ContainsAny(key.Name, `/\`) || strings.HasSuffix(key.Name, ".pub") {
+		return false, fmt.Errorf("invalid ssh key name %q, expected a file name such as id_ed25519", key.Name)
+	}
+	path := filepath.Join(dir, key.Name)
+	if _, err := os.Stat(path); err == nil {
+		if opts.DryRun {
+			return false, nil
+		}
+		return false, os.Chmod(path, 0600)
+	}
+	if opts.DryRun {
+		fmt.Printf("Would generate ssh key %s\n", path)
+		return true, nil
+	}
+
+	comment := key.Comment
+	if comment == "" {
+		comment = defaultKeyComment()
+	}
+	var passphrase string
+	if opts.Interactive {
+		var err error
+		if passphrase, err = askPassphrase(path); err != nil {
+			return false, err
+		}
+	} else {
+		fmt.Printf("Generating %s without a passphrase, add one with: ssh-keygen -p -f %s\n", path, path)
+	}
+
+	fmt.Printf("Generating ssh key %s\n", path)
+	if err := generateSSHKey(path, comment, passphrase); err != nil {
+		return false, fmt.Errorf("failed to generate ssh key %s: %v", path, err)
+	}
+	return true, nil
+}
+
+// generateSSHKey writes a new ed25519 key pair to path and path.pub in the
+// OpenSSH format. It is generated in-process rather than with ssh-keygen so
+// that the passphrase never appears in a process list.
+func generateSSHKey(path, comment, passphrase string) error {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(private, comment)
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(private, comment, []byte(passphrase))
+	}
+	if err != nil {
+		return err
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return err
+	}
+	authorized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPublic)), "\n") + " " + comment + "\n"
+
+	// The private key goes last: once it exists the key counts as generated.
+	if err := utils.WriteFileAtomic(path+".pub", []byte(authorized), 0644); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, pem.EncodeToMemory(block), 0600)
+}
+
+func askPassphrase(path string) (string, error) {
+	var passphrase, repeated string
+	if err := askOne(&survey.Password{Message: fmt.Sprintf("Passphrase for %s (empty for none):", path)}, &passphrase); err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", nil
+	}
+	utils.RegisterSecret(passphrase)
+	if err := askOne(&survey.Password{Message: "Repeat the passphrase:"}, &repeated); err != nil {
+		return "", err
+	}
+	if repeated != passphrase {
+		return "", fmt.Errorf("the passphrases for %s don't match", path)
+	}
+	return passphrase, nil
+}
+
+func defaultKeyComment() string {
+	name := "mycli"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		name += "@" + hostname
+	}
+	return name
+}
+
+// setSSHHost writes host's entry to the managed block "ssh <host>" of the ssh
+// config, reporting whether that changed it. ssh uses the first value it
+// finds for each keyword, so the block goes above the sections the user wrote
+// lest a Host * or Match there override it.
+func setSSHHost(configPath string, host utils.SSHHost, dryRun bool) (bool, error) {
+	if host.Host == "" {
+		return false, fmt.Errorf("ssh hosts need a host pattern")
+	}
+	name, body := "ssh "+host.Host, sshHostBlock(host)
+	if !dryRun {
+		return utils.SetManagedBlockBefore(configPath, name, body, sshSectionStart)
+	}
+	content, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %v", configPath, err)
+	}
+	if utils.UpsertBlockBefore(string(content), name, body, sshSectionStart) == string(content) {
+		return false, nil
+	}
+	fmt.Printf("Would set Host %s in %s\n", host.Host, configPath)
+	return true, nil
+}
+
+// sshSectionStart matches the Host and Match lines starting a section of the
+// ssh config.
+func sshSectionStart(line string) bool {
+	fields := strings.FieldsFunc(line, func(r rune) bool { return unicode.IsSpace(r) || r == '=' })
+	return len(fields) > 0 && (strings.EqualFold(fields[0], "Host") || strings.EqualFold(fields[0], "Match"))
+}
+
+func sshHostBlock(host utils.SSHHost) string {
+	lines := []string{"Host " + host.Host}
+	add := func(keyword, value string) {
+		if value != "" {
+			lines = append(lines, "  "+keyword+" "+value)
+		}
+	}
+	add("HostName", host.HostName)
+	add("User", host.User)
+	if host.Port != 0 {
+		add("Port", fmt.Sprint(host.Port))
+	}
+	add("IdentityFile", host.IdentityFile)
+	for _, keyword := range sortedKeys(host.Options) {
+		add(keyword, host.Options[keyword])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PrintSSHPublicKeys prints the public keys of the ssh section for pasting
+// into GitHub and the like.
+func PrintSSHPublicKeys(iostream *iostreams.IOStreams, cfg *utils.SSHConfig) {
+	if cfg == nil {
+		return
+	}
+	cs := iostream.ColorScheme()
+	dir, err := sshDir()
+	if err != nil {
+		return
+	}
+	for _, key := range cfg.Keys {
+		path := filepath.Join(dir, key.Name+".pub")
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(iostream.Out, "\n%s\n%s\n", cs.Bold(fmt.Sprintf("Your SSH public key (%s):", path)), strings.TrimSpace(string(content)))
+	}
+}
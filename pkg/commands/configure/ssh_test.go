@@ -0,0 +1,138 @@
+package configure
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHHostBlock(t *testing.T) {
+	got := sshHostBlock(utils.SSHHost{
+		Host:         "github.com",
+		User:         "git",
+		Port:         22,
+		IdentityFile: "~/.ssh/id_ed25519",
+		Options:      map[string]string{"IdentitiesOnly": "yes", "AddKeysToAgent": "yes"},
+	})
+	assert.Equal(t, "Host github.com\n  User git\n  Port 22\n  IdentityFile ~/.ssh/id_ed25519\n  AddKeysToAgent yes\n  IdentitiesOnly yes", got)
+}
+
+func TestConfigureSSH(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".ssh")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config"), []byte("Host *\n  ServerAliveInterval 60\n"), 0644))
+
+	config := &utils.ToolConfig{
+		SSH: &utils.SSHConfig{
+			Keys:  []utils.SSHKey{{Name: "id_ed25519", Comment: "jane@example.com"}},
+			Hosts: []utils.SSHHost{{Host: "github.com", User: "git", IdentityFile: "~/.ssh/id_ed25519"}},
+		},
+	}
+	ios, _, out, _ := iostreams.Test()
+
+	stats, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, "dry run, 1 keys generated, 1 hosts set", stats[0].Detail)
+	_, err = os.Stat(filepath.Join(dir, "id_ed25519"))
+	assert.True(t, os.IsNotExist(err), "dry run should not generate keys")
+
+	originalAskOne := askOne
+	defer func() { askOne = originalAskOne }()
+	askOne = func(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+		*(response.(*string)) = "correct horse"
+		return nil
+	}
+	stats, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{Interactive: true})
+	require.NoError(t, err)
+	assert.Equal(t, "1 keys generated, 1 hosts set", stats[0].Detail)
+
+	modes := map[string]os.FileMode{"": 0700, "config": 0600, "id_ed25519": 0600, "id_ed25519.pub": 0644}
+	for name, want := range modes {
+		info, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Mode().Perm(), name)
+	}
+	// The key is encrypted with the passphrase and matches the public key.
+	private, err := os.ReadFile(filepath.Join(dir, "id_ed25519"))
+	require.NoError(t, err)
+	_, err = ssh.ParsePrivateKey(private)
+	require.Error(t, err)
+	signer, err := ssh.ParsePrivateKeyWithPassphrase(private, []byte("correct horse"))
+	require.NoError(t, err)
+	public, err := os.ReadFile(filepath.Join(dir, "id_ed25519.pub"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(signer.PublicKey())), "\n")+" jane@example.com\n", string(public))
+	if _, err := exec.LookPath("ssh-keygen"); err == nil {
+		require.NoError(t, exec.Command("ssh-keygen", "-y", "-P", "correct horse", "-f", filepath.Join(dir, "id_ed25519")).Run())
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "config"))
+	require.NoError(t, err)
+	// The block goes above Host *, which would otherwise take precedence.
+	assert.Equal(t, "# >>> mycli: ssh github.com >>>\nHost github.com\n  User git\n  IdentityFile ~/.ssh/id_ed25519\n# <<< mycli: ssh github.com <<<\n\nHost *\n  ServerAliveInterval 60\n", string(content))
+
+	// Existing keys and unchanged hosts are left alone.
+	stats, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "0 keys generated, 0 hosts set", stats[0].Detail)
+
+	out.Reset()
+	PrintSSHPublicKeys(ios, config.SSH)
+	assert.Contains(t, out.String(), "ssh-ed25519 ")
+	assert.Contains(t, out.String(), "jane@example.com")
+}
+
+func TestSetSSHHostAboveUserSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	existing := "AddKeysToAgent yes\n\n# Defaults\nHost *\n  User root\n  IdentityFile ~/.ssh/work\n\nMatch host=*.corp\n  Port 2222\n"
+	require.NoError(t, os.WriteFile(path, []byte(existing), 0600))
+
+	host := utils.SSHHost{Host: "github.com", User: "git", IdentityFile: "~/.ssh/id_ed25519"}
+	changed, err := setSSHHost(path, host, true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = setSSHHost(path, host, false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	want := "AddKeysToAgent yes\n\n# >>> mycli: ssh github.com >>>\nHost github.com\n  User git\n  IdentityFile ~/.ssh/id_ed25519\n# <<< mycli: ssh github.com <<<\n\n# Defaults\nHost *\n  User root\n  IdentityFile ~/.ssh/work\n\nMatch host=*.corp\n  Port 2222\n"
+	assert.Equal(t, want, string(content))
+
+	changed, err = setSSHHost(path, host, false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestSSHSectionStart(t *testing.T) {
+	for line, want := range map[string]bool{
+		"Host *":            true,
+		"  host=github.com": true,
+		"Match all":         true,
+		"MATCH\thost x":     true,
+		"HostName github":   false,
+		"# Host commented":  false,
+		"User git":          false,
+		"":                  false,
+	} {
+		assert.Equal(t, want, sshSectionStart(line), line)
+	}
+}
+
+func TestEnsureSSHKeyInvalidName(t *testing.T) {
+	_, err := ensureSSHKey(t.TempDir(), utils.SSHKey{Name: "../id_ed25519"}, ConfigureOptions{})
+	assert.EqualError(t, err, `invalid ssh key name "../id_ed25519", expected a file name such as id_ed25519`)
+}
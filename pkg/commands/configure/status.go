@@ -0,0 +1,445 @@
+package configure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	statusInSync          = "in-sync"
+	statusLocallyModified = "locally-modified"
+	statusUpstreamChanged = "upstream-changed"
+	statusMissing         = "missing"
+	statusConflict        = "conflict"
+	statusPermissionDrift = "permission-drift"
+	statusUnknown         = "unknown"
+	statusError           = "error"
+)
+
+// itemStatus is how an installed config compares with its source.
+type itemStatus struct {
+	Name   string
+	Path   string
+	Status string
+	Detail string
+	Diff   string // Unified diff from the installed config to the source, when requested
+}
+
+// newStatusCmd reports drift between installed configs and their sources.
+//
+// Usage:
+//
+//	mycli configure status [name...] [flags]
+//
+// Flags:
+//
+//	-c, --config string   Path to the configuration file (default "config.yaml")
+//	    --diff            Show how each drifted config differs from its source
+//	    --offline         Compare against cached downloads and repositories only
+func newStatusCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cs := iostream.ColorScheme()
+	var configFile string
+	var showDiff, offline bool
+
+	cmd := &cobra.Command{
+		Use:   "status [name...]",
+		Short: "Show which configs drifted from their sources",
+		Annotations: map[string]string{
+			"group": "configure",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "configure_status")
+			defer span.Finish()
+			if offline {
+				ctx = utils.WithOffline(ctx, true)
+			}
+
+			config, err := utils.LoadToolsConfig(configFile)
+			if err != nil {
+				fmt.Fprintf(iostream.ErrOut, cs.Red("Error loading configuration: %v\n"), err)
+				return utils.ConfigNotFoundError
+			}
+			statuses, err := configStatuses(ctx, config, args, showDiff)
+			if err != nil {
+				return err
+			}
+			printStatuses(iostream, statuses)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	cmd.Flags().BoolVar(&showDiff, "diff", false, "Show how each drifted config differs from its source")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Compare against cached downloads and repositories only")
+	return cmd
+}
+
+// configStatuses checks the named configure items, or all of them.
+func configStatuses(ctx context.Context, config *utils.ToolConfig, names []string, withDiff bool) ([]itemStatus, error) {
+	ctx = utils.WithAuth(ctx, config.Auth)
+	state, err := utils.LoadState()
+	if err != nil {
+		return nil, err
+	}
+
+	items := config.Configure
+	if len(names) > 0 {
+		items = nil
+		for _, name := range names {
+			item, err := config.GetConfigureItem(name)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, *item)
+		}
+	}
+
+	opts := ConfigureOptions{
+		vars:      make(map[string]string, len(config.Variables)),
+		checkouts: make(map[string]repoCheckout),
+		secrets:   newSecretResolver(ctx, config.SecretProviders),
+	}
+	for key, value := range config.Variables {
+		opts.vars[key] = value
+	}
+	statuses := make([]itemStatus, 0, len(items))
+	for _, item := range items {
+		statuses = append(statuses, checkStatus(ctx, item, state.Configured[item.Name], opts, withDiff))
+	}
+	if config.Git != nil && len(names) == 0 {
+		statuses = append(statuses, gitStatus(ctx, config.Git))
+	}
+	return statuses, nil
+}
+
+// checkStatus compares the installed config with what configure would install
+// now. The hash recorded when it was last installed tells which side changed.
+// Repos and archives are resolved like a dry run: status never clones, fetches,
+// checks out or extracts anything, and compares against the checkout and
+// extracted archive configure used last. Without those the upstream is unknown.
+func checkStatus(ctx context.Context, item utils.ConfigureItem, record utils.ConfiguredRecord, opts ConfigureOptions, withDiff bool) itemStatus {
+	installPath := expandTilde(item.InstallPath)
+	st := itemStatus{Name: item.Name, Path: installPath}
+	fail := func(err error) itemStatus {
+		st.Status, st.Detail = statusError, err.Error()
+		return st
+	}
+
+	if len(item.ConfigureCommand) > 0 {
+		st.Status, st.Detail = statusUnknown, "set up by configure_command"
+		return st
+	}
+	perms, err := itemPermissions(item)
+	if err != nil {
+		return fail(err)
+	}
+	switch {
+	case item.Repo != "":
+		item, _, err = resolveRepoSource(ctx, item, opts.checkouts, true)
+	case item.Archive != "":
+		item, _, err = resolveArchiveSource(ctx, item, true)
+	}
+	if errors.Is(err, utils.ErrNotCached) {
+		return notCached(st)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if item.Mode == modeSymlink {
+		return symlinkStatus(item, st)
+	}
+	if _, err := os.Lstat(installPath); os.IsNotExist(err) {
+		st.Status = statusMissing
+		return st
+	}
+
+	if item.SourcePath != "" {
+		source, err := sourcePath(item)
+		if err != nil {
+			return fail(err)
+		}
+		if info, err := os.Stat(source); err == nil && info.IsDir() {
+			return withPermissions(treeStatus(item, source, record, st, withDiff), permissionDrift(installPath, perms))
+		}
+	}
+
+	upstream, err := sourceContent(ctx, item, opts)
+	if errors.Is(err, utils.ErrNotCached) {
+		return notCached(st)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	local, err := os.ReadFile(installPath)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read %s: %v", installPath, err))
+	}
+	if item.Strategy == strategyMerge {
+		merged, changed, err := mergeInstalled(item, installPath, upstream)
+		if err != nil {
+			return fail(err)
+		}
+		st.Status = classifyMerge(changed, utils.SHA256Hex(upstream), record, installPath)
+		upstream = merged
+	} else {
+		st.Status = classify(utils.SHA256Hex(local), utils.SHA256Hex(upstream), record, installPath)
+	}
+	if withDiff && st.Status != statusInSync {
+		source := utils.RedactURL(item.ConfigURL)
+		if item.SourcePath != "" {
+			source = item.SourcePath
+		}
+		st.Diff = utils.Redact(utils.UnifiedDiff(installPath, source, local, upstream))
+	}
+	drift := permissionDrift(installPath, permissions{fileMode: perms.fileMode, uid: perms.uid, gid: perms.gid})
+	dir := filepath.Dir(installPath)
+	if home, err := os.UserHomeDir(); drift == "" && (err != nil || dir != filepath.Clean(home)) {
+		if info, err := os.Stat(dir); err == nil {
+			drift = fileDrift(dir, info, perms.dirMode, perms)
+		}
+	}
+	return withPermissions(st, drift)
+}
+
+// notCached reports an item whose source was never downloaded, cloned or
+// extracted, so there is nothing to compare the installed config with.
+func notCached(st itemStatus) itemStatus {
+	st.Status, st.Detail = statusUnknown, "upstream unknown, not cached; run mycli configure first"
+	return st
+}
+
+// withPermissions flags permission drift on an otherwise in-sync config, and
+// adds it to the detail of any other status.
+func withPermissions(st itemStatus, drift string) itemStatus {
+	switch {
+	case drift == "":
+	case st.Status == statusInSync:
+		st.Status, st.Detail = statusPermissionDrift, drift
+	case st.Detail == "":
+		st.Detail = drift
+	default:
+		st.Detail += "; " + drift
+	}
+	return st
+}
+
+// classify turns the hashes of the installed config, its source and the
+// source as last installed into a status. Without a record of the last
+// install there is no telling which side changed, so a difference is a
+// conflict.
+func classify(local, upstream string, record utils.ConfiguredRecord, installPath string) string {
+	switch {
+	case local == upstream:
+		return statusInSync
+	case record.SHA256 == "" || record.Path != installPath:
+		return statusConflict
+	case upstream == record.SHA256:
+		return statusLocallyModified
+	case local == record.SHA256:
+		return statusUpstreamChanged
+	}
+	return statusConflict
+}
+
+// classifyMerge is classify for configs merged into the installed file, which
+// is in sync as long as merging the source again wouldn't change it.
+func classifyMerge(changed bool, upstream string, record utils.ConfiguredRecord, installPath string) string {
+	switch {
+	case !changed:
+		return statusInSync
+	case record.SHA256 == "" || record.Path != installPath:
+		return statusConflict
+	case upstream == record.SHA256:
+		return statusLocallyModified
+	}
+	return statusUpstreamChanged
+}
+
+func symlinkStatus(item utils.ConfigureItem, st itemStatus) itemStatus {
+	source, err := sourcePath(item)
+	if err != nil {
+		st.Status, st.Detail = statusError, err.Error()
+		return st
+	}
+	if _, err := os.Lstat(st.Path); os.IsNotExist(err) {
+		st.Status = statusMissing
+		return st
+	}
+	target, _, err := linkTarget(st.Path)
+	switch {
+	case err != nil:
+		st.Status, st.Detail = statusLocallyModified, "replaced by a regular file"
+	case target != source:
+		st.Status, st.Detail = statusLocallyModified, "links to "+target
+	default:
+		st.Status = statusInSync
+	}
+	return st
+}
+
+// treeStatus compares an installed directory with its source directory. Files
+// that only exist in the installed directory count unless the item prunes
+// them, since configure leaves them alone otherwise.
+func treeStatus(item utils.ConfigureItem, source string, record utils.ConfiguredRecord, st itemStatus, withDiff bool) itemStatus {
+	upstream, err := treeDigest(source)
+	if err != nil {
+		st.Status, st.Detail = statusError, err.Error()
+		return st
+	}
+	local, err := treeDigest(st.Path)
+	if err != nil {
+		st.Status, st.Detail = statusError, err.Error()
+		return st
+	}
+	if !item.Prune {
+		for rel := range local {
+			if _, ok := upstream[rel]; !ok {
+				delete(local, rel)
+			}
+		}
+	}
+
+	st.Status = classify(digestHash(local), digestHash(upstream), record, st.Path)
+	if !withDiff || st.Status == statusInSync {
+		return st
+	}
+	paths := make(map[string]bool, len(local)+len(upstream))
+	for rel := range local {
+		paths[rel] = true
+	}
+	for rel := range upstream {
+		paths[rel] = true
+	}
+	for _, rel := range sortedKeys(paths) {
+		if local[rel] == upstream[rel] {
+			continue
+		}
+		installed, source := filepath.Join(st.Path, rel), filepath.Join(source, rel)
+		a, _ := os.ReadFile(installed)
+		b, _ := os.ReadFile(source)
+		st.Diff += utils.Redact(utils.UnifiedDiff(installed, source, a, b))
+	}
+	return st
+}
+
+// treeDigest describes every file and symlink under dir, keyed by slash
+// separated relative path. Like copyTree, it skips nested .git directories.
+func treeDigest(dir string) (map[string]string, error) {
+	entries := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			entries[filepath.ToSlash(rel)] = "link:" + link
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		entries[filepath.ToSlash(rel)] = "sha256:" + utils.SHA256Hex(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+	return entries, nil
+}
+
+// digestHash combines a treeDigest into a single hash.
+func digestHash(entries map[string]string) string {
+	h := sha256.New()
+	for _, rel := range sortedKeys(entries) {
+		fmt.Fprintf(h, "%s\x00%s\n", rel, entries[rel])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// recordConfigured remembers the hash of what was installed for name, which
+// configure status compares against later.
+func recordConfigured(name, installPath, hash string) error {
+	state, err := utils.LoadState()
+	if err != nil {
+		return err
+	}
+	if state.Configured == nil {
+		state.Configured = make(map[string]utils.ConfiguredRecord)
+	}
+	state.Configured[name] = utils.ConfiguredRecord{Path: installPath, SHA256: hash, Installed: time.Now()}
+	if err := utils.SaveState(state); err != nil {
+		return fmt.Errorf("failed to record %s: %v", name, err)
+	}
+	return nil
+}
+
+func printStatuses(iostream *iostreams.IOStreams, statuses []itemStatus) {
+	cs := iostream.ColorScheme()
+	if len(statuses) == 0 {
+		fmt.Fprintln(iostream.Out, "No configs to check.")
+		return
+	}
+
+	table := tablewriter.NewWriter(iostream.Out)
+	table.SetHeader([]string{"Name", "Status", "Path", "Detail"})
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.FgGreenColor},
+	)
+	for _, st := range statuses {
+		color := tablewriter.FgYellowColor
+		switch st.Status {
+		case statusInSync:
+			color = tablewriter.FgGreenColor
+		case statusMissing, statusConflict, statusError:
+			color = tablewriter.FgRedColor
+		}
+		table.Rich([]string{st.Name, st.Status, st.Path, st.Detail}, []tablewriter.Colors{{}, {color}, {}, {}})
+	}
+	table.Render()
+
+	for _, st := range statuses {
+		if st.Diff != "" {
+			fmt.Fprintln(iostream.Out, cs.Bold(st.Name))
+			utils.PrintDiff(iostream, st.Diff)
+		}
+	}
+}
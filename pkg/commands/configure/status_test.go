@@ -0,0 +1,133 @@
+package configure
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	record := utils.ConfiguredRecord{Path: "/home/me/.zshrc", SHA256: "base"}
+	tests := []struct {
+		name            string
+		local, upstream string
+		record          utils.ConfiguredRecord
+		want            string
+	}{
+		{"In sync", "a", "a", record, statusInSync},
+		{"Edited locally", "local", "base", record, statusLocallyModified},
+		{"Changed upstream", "base", "upstream", record, statusUpstreamChanged},
+		{"Both changed", "local", "upstream", record, statusConflict},
+		{"Never recorded", "local", "upstream", utils.ConfiguredRecord{}, statusConflict},
+		{"Recorded for another path", "base", "upstream", utils.ConfiguredRecord{Path: "/elsewhere", SHA256: "base"}, statusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classify(tt.local, tt.upstream, tt.record, "/home/me/.zshrc"))
+		})
+	}
+}
+
+func TestConfigStatuses(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	source := filepath.Join(home, "dotfiles", "zshrc")
+	tree := filepath.Join(home, "dotfiles", "nvim")
+	require.NoError(t, os.MkdirAll(tree, 0755))
+	require.NoError(t, os.WriteFile(source, []byte("v1\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tree, "init.lua"), []byte("-- v1\n"), 0644))
+
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "zsh", SourcePath: source, InstallPath: "~/.zshrc"},
+			{Name: "nvim", SourcePath: tree, InstallPath: "~/.config/nvim"},
+			{Name: "tmux", ConfigureCommand: []string{"true"}, InstallPath: "~/.tmux.conf"},
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	_, err := ConfigureToolsFromConfig(ios, &utils.ToolConfig{Configure: config.Configure[:2]}, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+
+	status := func(name string, withDiff bool) itemStatus {
+		statuses, err := configStatuses(context.Background(), config, []string{name}, withDiff)
+		require.NoError(t, err)
+		require.Len(t, statuses, 1)
+		return statuses[0]
+	}
+	assert.Equal(t, statusInSync, status("zsh", false).Status)
+	assert.Equal(t, statusInSync, status("nvim", false).Status)
+	assert.Equal(t, statusUnknown, status("tmux", false).Status)
+
+	installed := filepath.Join(home, ".zshrc")
+	require.NoError(t, os.WriteFile(installed, []byte("local\n"), 0644))
+	st := status("zsh", true)
+	assert.Equal(t, statusLocallyModified, st.Status)
+	assert.Contains(t, st.Diff, "-local\n+v1\n")
+
+	require.NoError(t, os.WriteFile(installed, []byte("v1\n"), 0644))
+	require.NoError(t, os.WriteFile(source, []byte("v2\n"), 0644))
+	assert.Equal(t, statusUpstreamChanged, status("zsh", false).Status)
+
+	require.NoError(t, os.WriteFile(installed, []byte("local\n"), 0644))
+	assert.Equal(t, statusConflict, status("zsh", false).Status)
+
+	require.NoError(t, os.Remove(installed))
+	assert.Equal(t, statusMissing, status("zsh", false).Status)
+
+	// Extra files in an installed directory don't count unless the item prunes.
+	extra := filepath.Join(home, ".config", "nvim", "extra.lua")
+	require.NoError(t, os.WriteFile(extra, []byte("-- mine\n"), 0644))
+	assert.Equal(t, statusInSync, status("nvim", false).Status)
+	config.Configure[1].Prune = true
+	st = status("nvim", true)
+	assert.Equal(t, statusLocallyModified, st.Status)
+	assert.Contains(t, st.Diff, "-- mine")
+
+	_, err = configStatuses(context.Background(), config, []string{"vim"}, false)
+	assert.EqualError(t, err, "configuration for vim not found")
+}
+
+func TestRepoStatusUsesCache(t *testing.T) {
+	repo, _, second := newTestRepo(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	config := &utils.ToolConfig{
+		Configure: []utils.ConfigureItem{
+			{Name: "nvim", Repo: repo, RepoPath: "nvim", InstallPath: "~/.config/nvim"},
+		},
+	}
+	dir, err := repoCacheDir(repo, "")
+	require.NoError(t, err)
+
+	statuses, err := configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusUnknown, statuses[0].Status)
+	assert.Contains(t, statuses[0].Detail, "upstream unknown")
+	assert.NoDirExists(t, dir, "status must not clone")
+
+	ios, _, _, _ := iostreams.Test()
+	_, err = ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+	statuses, err = configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, statusInSync, statuses[0].Status)
+
+	// A new upstream commit isn't fetched by status.
+	cmd := exec.Command("git", "commit", "--quiet", "--allow-empty", "-m", "v3")
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	require.NoError(t, cmd.Run())
+	_, err = configStatuses(context.Background(), config, nil, false)
+	require.NoError(t, err)
+	commit, err := git(context.Background(), dir, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, second, commit)
+}
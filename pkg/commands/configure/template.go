@@ -0,0 +1,159 @@
+package configure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+var askOne = survey.AskOne
+
+// templateData is what a templated config is rendered against:
+//
+//	{{ .Vars.email }}    values from the variables section of the config (or prompted)
+//	{{ .Env.HOME }}      environment variables
+//	{{ .Facts.os }}      facts about the machine: os, arch, hostname, user, home
+//	{{ secret "env:X" }} a secret, resolved at configure time (see secretResolver)
+//
+// Rendering is strict: referring to a key that doesn't exist is an error.
+type templateData struct {
+	Vars  map[string]string
+	Env   map[string]string
+	Facts map[string]string
+}
+
+// renderTemplate renders content as a text/template. Variables the template
+// uses but the config doesn't define are prompted for when interactive is set;
+// answers are stored in vars so later items in the same run reuse them.
+func renderTemplate(name string, content []byte, vars map[string]string, interactive bool, secrets *secretResolver) ([]byte, error) {
+	if secrets == nil {
+		secrets = newSecretResolver(context.Background(), nil)
+	}
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"secret": secrets.resolve}).
+		Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	for _, key := range referencedVars(tmpl) {
+		if _, ok := vars[key]; ok {
+			continue
+		}
+		if !interactive {
+			return nil, fmt.Errorf("template variable %q is not set, add it to the variables section of the config", key)
+		}
+		var value string
+		if err := askOne(&survey.Input{Message: fmt.Sprintf("Value for %s (used by %s):", key, name)}, &value); err != nil {
+			return nil, err
+		}
+		vars[key] = value
+	}
+
+	data := templateData{Vars: vars, Env: environ(), Facts: machineFacts()}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, errors.New(utils.Redact(fmt.Sprintf("failed to render template: %v", err)))
+	}
+	return out.Bytes(), nil
+}
+
+// referencedVars returns the keys of every .Vars.<key> reference in tmpl.
+func referencedVars(tmpl *template.Template) []string {
+	seen := make(map[string]bool)
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			if len(n.Ident) >= 2 && n.Ident[0] == "Vars" {
+				seen[n.Ident[1]] = true
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+func machineFacts() map[string]string {
+	facts := map[string]string{
+		"os":   runtime.GOOS,
+		"arch": runtime.GOARCH,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		facts["hostname"] = hostname
+	}
+	if u, err := user.Current(); err == nil {
+		facts["user"] = u.Username
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		facts["home"] = home
+	}
+	return facts
+}
@@ -0,0 +1,111 @@
+package configure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"text/template"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubPrompt(t *testing.T, answers map[string]string) *[]string {
+	asked := []string{}
+	oldAskOne := askOne
+	askOne = func(p survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+		message := p.(*survey.Input).Message
+		asked = append(asked, message)
+		for key, answer := range answers {
+			if message == "Value for "+key+" (used by git):" {
+				*(response.(*string)) = answer
+			}
+		}
+		return nil
+	}
+	t.Cleanup(func() { askOne = oldAskOne })
+	return &asked
+}
+
+func TestRenderTemplate(t *testing.T) {
+	t.Setenv("MYCLI_TEST_EDITOR", "nvim")
+	content := []byte("[user]\n\tname = {{ .Vars.name }}\n\temail = {{ .Vars.email }}\n[core]\n\teditor = {{ .Env.MYCLI_TEST_EDITOR }}\n# {{ .Facts.os }}\n")
+
+	t.Run("all values supplied", func(t *testing.T) {
+		vars := map[string]string{"name": "Ada", "email": "ada@example.com"}
+		out, err := renderTemplate("git", content, vars, false, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "[user]\n\tname = Ada\n\temail = ada@example.com\n[core]\n\teditor = nvim\n# "+runtime.GOOS+"\n", string(out))
+	})
+
+	t.Run("missing variable without prompting", func(t *testing.T) {
+		_, err := renderTemplate("git", content, map[string]string{"name": "Ada"}, false, nil)
+		assert.EqualError(t, err, `template variable "email" is not set, add it to the variables section of the config`)
+	})
+
+	t.Run("missing variable is prompted once", func(t *testing.T) {
+		asked := stubPrompt(t, map[string]string{"email": "ada@example.com"})
+		vars := map[string]string{"name": "Ada"}
+
+		out, err := renderTemplate("git", content, vars, true, nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "email = ada@example.com")
+		assert.Equal(t, "ada@example.com", vars["email"])
+
+		_, err = renderTemplate("git", content, vars, true, nil)
+		require.NoError(t, err)
+		assert.Len(t, *asked, 1)
+	})
+
+	t.Run("missing environment variable is an error", func(t *testing.T) {
+		_, err := renderTemplate("git", []byte("{{ .Env.MYCLI_DOES_NOT_EXIST }}"), map[string]string{}, false, nil)
+		assert.ErrorContains(t, err, "MYCLI_DOES_NOT_EXIST")
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		_, err := renderTemplate("git", []byte("{{ .Vars.name "), map[string]string{}, false, nil)
+		assert.ErrorContains(t, err, "failed to parse template")
+	})
+}
+
+func TestReferencedVars(t *testing.T) {
+	tmpl, err := template.New("x").Parse(`{{ if .Vars.work }}{{ range .Vars.list }}{{ .Env.X }}{{ end }}{{ else }}{{ .Vars.home | printf "%s" }}{{ end }}{{ .Vars.home }}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"home", "list", "work"}, referencedVars(tmpl))
+}
+
+func TestConfigureToolsFromConfigTemplate(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("email = {{ .Vars.email }}\n"))
+	}))
+	defer testServer.Close()
+
+	config := &utils.ToolConfig{
+		Variables: map[string]string{"email": "ada@example.com"},
+		Configure: []utils.ConfigureItem{
+			{Name: "git", ConfigURL: testServer.URL, InstallPath: filepath.Join(tempDir, "templated"), Template: true},
+			{Name: "raw", ConfigURL: testServer.URL, InstallPath: filepath.Join(tempDir, "raw")},
+		},
+	}
+
+	ios, _, _, _ := iostreams.Test()
+	_, err := ConfigureToolsFromConfig(ios, config, context.Background(), ConfigureOptions{})
+	require.NoError(t, err)
+
+	templated, err := os.ReadFile(filepath.Join(tempDir, "templated"))
+	require.NoError(t, err)
+	assert.Equal(t, "email = ada@example.com\n", string(templated))
+
+	raw, err := os.ReadFile(filepath.Join(tempDir, "raw"))
+	require.NoError(t, err)
+	assert.Equal(t, "email = {{ .Vars.email }}\n", string(raw))
+}
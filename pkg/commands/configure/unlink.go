@@ -0,0 +1,101 @@
+package configure
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// newUnlinkCmd removes the symlinks created for configure items with mode symlink.
+//
+// Usage:
+//
+//	mycli configure unlink [name...] [flags]
+//
+// Flags:
+//
+//	-c, --config string   Path to the configuration file (default "config.yaml")
+func newUnlinkCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cs := iostream.ColorScheme()
+	var configFile string
+
+	cmd := &cobra.Command{
+		Use:   "unlink [name...]",
+		Short: "Remove symlinks created for configure items with mode symlink",
+		Annotations: map[string]string{
+			"group": "configure",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, _ := tracer.StartSpanFromContext(cmd.Context(), "configure_unlink")
+			defer span.Finish()
+
+			config, err := utils.LoadToolsConfig(configFile)
+			if err != nil {
+				fmt.Fprintf(iostream.ErrOut, cs.Red("Error loading configuration: %v\n"), err)
+				return utils.ConfigNotFoundError
+			}
+
+			items, err := symlinkItems(config.Configure, args)
+			if err != nil {
+				return err
+			}
+
+			var stats []*utils.Stats
+			var failed error
+			for _, item := range items {
+				start := time.Now()
+				stat := &utils.Stats{Name: item.Name, Operation: "Unlink"}
+				result, err := unlinkConfig(item)
+				switch {
+				case err != nil:
+					fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to unlink %s: %v\n"), item.Name, err)
+					stat.Status = "error"
+					failed = fmt.Errorf("failed to unlink some configs")
+				case result == "not linked":
+					fmt.Fprintf(iostream.Out, "%s: %s\n", item.Name, result)
+					stat.Status = "skipped"
+				default:
+					fmt.Fprintf(iostream.Out, cs.Green("%s: %s\n"), item.Name, result)
+					stat.Status = "success"
+				}
+				stat.Duration = time.Since(start)
+				stats = append(stats, stat)
+			}
+			utils.PrintCombinedStats(iostream, stats)
+			return failed
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	return cmd
+}
+
+// symlinkItems returns the symlink-mode items named in args, or all of them
+// when args is empty.
+func symlinkItems(items []utils.ConfigureItem, args []string) ([]utils.ConfigureItem, error) {
+	byName := make(map[string]utils.ConfigureItem)
+	var linked []utils.ConfigureItem
+	for _, item := range items {
+		if item.Mode == modeSymlink {
+			byName[item.Name] = item
+			linked = append(linked, item)
+		}
+	}
+	if len(args) == 0 {
+		return linked, nil
+	}
+
+	selected := make([]utils.ConfigureItem, 0, len(args))
+	for _, name := range args {
+		item, ok := byName[name]
+		if !ok {
+			return nil, utils.FlagErrorf("%s is not a configure item with mode symlink", name)
+		}
+		selected = append(selected, item)
+	}
+	return selected, nil
+}
@@ -0,0 +1,58 @@
+package configure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlinkCmd(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	source := filepath.Join(home, "zshrc")
+	require.NoError(t, os.WriteFile(source, []byte(""), 0644))
+	require.NoError(t, os.Symlink(source, filepath.Join(home, ".zshrc")))
+
+	configFile := filepath.Join(home, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`configure:
+  - name: zsh
+    source_path: `+source+`
+    install_path: ~/.zshrc
+    mode: symlink
+  - name: vim
+    config_url: https://example.com/vimrc
+    install_path: ~/.vimrc
+`), 0644))
+
+	ios, _, stdout, _ := iostreams.Test()
+	cmd := newUnlinkCmd(ios)
+	cmd.SetArgs([]string{"-c", configFile})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "zsh: unlinked")
+	_, err := os.Lstat(filepath.Join(home, ".zshrc"))
+	assert.True(t, os.IsNotExist(err))
+
+	cmd = newUnlinkCmd(ios)
+	cmd.SetArgs([]string{"-c", configFile, "vim"})
+	assert.EqualError(t, cmd.Execute(), "vim is not a configure item with mode symlink")
+}
+
+func TestSymlinkItems(t *testing.T) {
+	items := []utils.ConfigureItem{
+		{Name: "zsh", Mode: modeSymlink},
+		{Name: "vim"},
+		{Name: "nvim", Mode: modeSymlink},
+	}
+	all, err := symlinkItems(items, nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	selected, err := symlinkItems(items, []string{"nvim"})
+	require.NoError(t, err)
+	assert.Equal(t, "nvim", selected[0].Name)
+}
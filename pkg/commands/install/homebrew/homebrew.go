@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -19,13 +20,34 @@ import (
 
 var execCommandContext = exec.CommandContext
 
+var goos = runtime.GOOS
+
+const (
+	installScriptURL = "https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh"
+	// brewVersion is the Homebrew release --user installs unless told otherwise.
+	brewVersion = "4.4.0"
+)
+
+// brewTarballURL is the source tarball of a Homebrew release, by tag. It is a
+// variable so tests can point it at a local server.
+var brewTarballURL = "https://github.com/Homebrew/brew/archive/refs/tags/%s.tar.gz"
+
 // NewCmdHomeBrew creates a new cobra.Command that installs Homebrew on the system.
 // It checks if the current user is an administrator, and if so, runs the Homebrew
 // installation script using the current user's credentials. If the current user
 // is not an administrator, it prints an error message and exits.
+//
+// On Linux the build prerequisites are installed first and Homebrew ends up in
+// the Linuxbrew prefix (/home/linuxbrew/.linuxbrew).
+//
+// With --user, no admin rights are needed: the tarball of a pinned Homebrew
+// release, checked against --sha256 when given, is unpacked into a user-owned
+// prefix (~/.homebrew by default), which mycli remembers for later runs.
 func NewCmdHomeBrew(iostream *iostreams.IOStreams, userUtils utils.UserUtils, statsCollector *utils.StatsCollector) *cobra.Command {
 	cs := iostream.ColorScheme()
 	var stats *utils.Stats
+	var userInstall bool
+	var userPrefix, userVersion, userSHA256 string
 
 	cmd := &cobra.Command{
 		Use:   "homebrew",
@@ -46,6 +68,7 @@ func NewCmdHomeBrew(iostream *iostreams.IOStreams, userUtils utils.UserUtils, st
 			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "install_homebrew")
 			defer span.Finish()
 
+			UseRememberedPrefix()
 			isAdmin := userUtils.IsAdmin(ctx, currentUser)
 			if IsHomebrewInstalled(ctx) {
 				fmt.Fprintln(iostream.Out, "Homebrew is installed.")
@@ -58,8 +81,26 @@ func NewCmdHomeBrew(iostream *iostreams.IOStreams, userUtils utils.UserUtils, st
 				return nil
 			}
 
+			if userInstall {
+				err := installToUserPrefix(ctx, iostream, userPrefix, userVersion, userSHA256)
+				stats.Duration = time.Since(startTime)
+				if err != nil {
+					fmt.Fprintf(iostream.ErrOut, "Failed to install Homebrew: %v\n", err)
+					stats.Status = "error"
+					statsCollector.AddStat(stats)
+					span.SetTag("error", true)
+					span.Finish(tracer.WithError(err))
+					return err
+				}
+				stats.Status = "success"
+				statsCollector.AddStat(stats)
+				span.SetTag("status", "success")
+				return nil
+			}
+
 			if !isAdmin {
 				fmt.Fprintln(iostream.ErrOut, cs.Red("You need to be an administrator to install Homebrew. Please run this command from an admin account."))
+				fmt.Fprintln(iostream.ErrOut, "Alternatively, rerun with --user to install Homebrew into a prefix you own, such as ~/.homebrew.")
 				duration := time.Since(startTime)
 				stats.Duration = duration
 				stats.Status = "error"
@@ -69,8 +110,23 @@ func NewCmdHomeBrew(iostream *iostreams.IOStreams, userUtils utils.UserUtils, st
 				return os.ErrPermission
 			}
 
-			fmt.Fprint(iostream.Out, cs.Green("Installing homebrew with su current user, enter your password when prompt\n"))
-			installCmd := execCommandContext(ctx, "su", currentUser.Username, "-c", `/bin/bash -c "$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)"`)
+			var installCmd *exec.Cmd
+			if goos == "linux" {
+				if err := installLinuxPrerequisites(ctx, iostream); err != nil {
+					fmt.Fprintf(iostream.ErrOut, "Failed to install Homebrew prerequisites: %v\n", err)
+					stats.Duration = time.Since(startTime)
+					stats.Status = "error"
+					statsCollector.AddStat(stats)
+					span.SetTag("error", true)
+					span.Finish(tracer.WithError(err))
+					return err
+				}
+				fmt.Fprint(iostream.Out, cs.Green("Installing homebrew into /home/linuxbrew/.linuxbrew, enter your password when prompt\n"))
+				installCmd = execCommandContext(ctx, "bash", "-c", fmt.Sprintf(`/bin/bash -c "$(curl -fsSL %s)"`, installScriptURL))
+			} else {
+				fmt.Fprint(iostream.Out, cs.Green("Installing homebrew with su current user, enter your password when prompt\n"))
+				installCmd = execCommandContext(ctx, "su", currentUser.Username, "-c", fmt.Sprintf(`/bin/bash -c "$(curl -fsSL %s)"`, installScriptURL))
+			}
 
 			installCmd.Stdout = os.Stdout
 			installCmd.Stderr = os.Stderr
@@ -103,10 +159,102 @@ func NewCmdHomeBrew(iostream *iostreams.IOStreams, userUtils utils.UserUtils, st
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&userInstall, "user", false, "Install Homebrew into a user-owned prefix, no admin rights needed")
+	cmd.Flags().StringVar(&userPrefix, "prefix", "~/.homebrew", "Prefix for --user installs")
+	cmd.Flags().StringVar(&userVersion, "version", brewVersion, "Homebrew release for --user installs")
+	cmd.Flags().StringVar(&userSHA256, "sha256", "", "Expected SHA-256 of the Homebrew release tarball for --user installs")
 
 	return cmd
 }
 
+// installToUserPrefix installs Homebrew without admin rights by unpacking the
+// tarball of release version into prefix (the "untar anywhere" method), and
+// remembers prefix so later mycli runs find brew there. The tarball is
+// downloaded with utils.Download and checked against sha256 when set; without
+// one, its checksum is printed so it can be pinned next time.
+func installToUserPrefix(ctx context.Context, iostream *iostreams.IOStreams, prefix, version, sha256 string) error {
+	cs := iostream.ColorScheme()
+	if strings.HasPrefix(prefix, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %v", err)
+		}
+		prefix = filepath.Join(home, prefix[1:])
+	}
+
+	fmt.Fprintf(iostream.Out, cs.Green("Installing homebrew %s into %s, no admin rights needed\n"), version, prefix)
+	tarballURL := fmt.Sprintf(brewTarballURL, version)
+	tarball, err := utils.Download(ctx, tarballURL)
+	if err != nil {
+		return err
+	}
+	if sha256 == "" {
+		fmt.Fprintf(iostream.ErrOut, cs.Yellow("The Homebrew %s tarball isn't pinned; its SHA-256 is %s, pass it with --sha256 to verify it\n"), version, utils.SHA256Hex(tarball))
+	} else if err := utils.VerifySHA256(tarballURL, tarball, sha256); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "mycli-homebrew-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to save Homebrew tarball: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(tarball); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save Homebrew tarball: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save Homebrew tarball: %v", err)
+	}
+	command := fmt.Sprintf("mkdir -p %s && tar xzf %s --strip-components 1 -C %s", shellQuote(prefix), shellQuote(f.Name()), shellQuote(prefix))
+	if err := executeCommand(command, ctx); err != nil {
+		return err
+	}
+
+	state, err := utils.LoadState()
+	if err != nil {
+		return err
+	}
+	state.HomebrewPrefix = prefix
+	if err := utils.SaveState(state); err != nil {
+		return fmt.Errorf("failed to remember Homebrew prefix: %v", err)
+	}
+	UseRememberedPrefix()
+
+	if err := executeCommand("brew update --force --quiet", ctx); err != nil {
+		return err
+	}
+
+	if err := updatePath(ctx, iostream); err != nil {
+		fmt.Fprintf(iostream.ErrOut, "Warning: %v\n", err)
+	} else {
+		fmt.Fprintln(iostream.Out, cs.Green("Homebrew installed successfully and PATH updated."))
+	}
+	return nil
+}
+
+// UseRememberedPrefix puts the bin directory of a Homebrew installed with --user
+// on PATH, so brew commands run by mycli find it even before the user's shell
+// config has been reloaded. It does nothing for regular installs.
+func UseRememberedPrefix() {
+	state, err := utils.LoadState()
+	if err != nil || state.HomebrewPrefix == "" {
+		return
+	}
+	_ = prependPath(filepath.Join(state.HomebrewPrefix, "bin"))
+}
+
+func prependPath(dir string) error {
+	currentPath := os.Getenv("PATH")
+	if strings.Contains(currentPath, dir) {
+		return nil
+	}
+	if err := os.Setenv("PATH", fmt.Sprintf("%s:%s", dir, currentPath)); err != nil {
+		return fmt.Errorf("failed to update PATH: %v", err)
+	}
+	return nil
+}
+
 // IsHomebrewInstalled checks if Homebrew is installed on the system.
 func IsHomebrewInstalled(ctx context.Context) bool {
 	// The 'which' command searches for the Homebrew executable in the system path.
@@ -120,58 +268,99 @@ func IsHomebrewInstalled(ctx context.Context) bool {
 	return strings.Contains(string(output), "/brew")
 }
 
-func updatePath(ctx context.Context, iostream *iostreams.IOStreams) error {
-	homebrewPath := "/opt/homebrew/bin" // Default path for Apple Silicon Macs
-	if _, err := os.Stat(homebrewPath); os.IsNotExist(err) {
-		homebrewPath = "/usr/local/bin" // Default path for Intel Macs
+// installLinuxPrerequisites installs the packages the Homebrew installer needs
+// on Linux, using whichever of apt-get or dnf is available.
+func installLinuxPrerequisites(ctx context.Context, iostream *iostreams.IOStreams) error {
+	var command string
+	switch {
+	case hasCommand("apt-get"):
+		command = "sudo apt-get update && sudo apt-get install -y build-essential procps curl file git"
+	case hasCommand("dnf"):
+		command = "sudo dnf group install -y 'Development Tools' && sudo dnf install -y procps-ng curl file git"
+	default:
+		fmt.Fprintln(iostream.ErrOut, iostream.ColorScheme().Yellow("Neither apt-get nor dnf found, make sure gcc, curl, file and git are installed."))
+		return nil
 	}
+	fmt.Fprintln(iostream.Out, "Installing Homebrew prerequisites...")
+	return executeCommand(command, ctx)
+}
 
-	currentPath := os.Getenv("PATH")
-	if !strings.Contains(currentPath, homebrewPath) {
-		newPath := fmt.Sprintf("%s:%s", homebrewPath, currentPath)
-		if err := os.Setenv("PATH", newPath); err != nil {
-			return fmt.Errorf("failed to update PATH: %v", err)
+func hasCommand(name string) bool {
+	_, err := lookPath(name)
+	return err == nil
+}
+
+// detectBrewPrefix returns the Homebrew prefix for this machine: the prefix
+// remembered from a --user install, the first standard location that contains
+// a brew binary, or the platform default otherwise.
+var detectBrewPrefix = func() string {
+	if state, err := utils.LoadState(); err == nil && state.HomebrewPrefix != "" {
+		return state.HomebrewPrefix
+	}
+	candidates := []string{"/opt/homebrew", "/usr/local"} // Apple Silicon, then Intel
+	if goos == "linux" {
+		candidates = []string{"/home/linuxbrew/.linuxbrew"}
+		if home, err := os.UserHomeDir(); err == nil {
+			candidates = append(candidates, filepath.Join(home, ".linuxbrew"))
+		}
+	}
+	for _, prefix := range candidates {
+		if _, err := os.Stat(filepath.Join(prefix, "bin", "brew")); err == nil {
+			return prefix
 		}
 	}
+	if goos == "darwin" && runtime.GOARCH != "arm64" {
+		return candidates[1]
+	}
+	return candidates[0]
+}
+
+// shellenvConfig returns the rc file (relative to the home directory) for the
+// user's login shell and the line that loads `brew shellenv` in that shell.
+func shellenvConfig(prefix string) (string, string) {
+	shell := utils.DetectShell()
+	return shell.RCFile(goos), shell.EvalLine(filepath.Join(prefix, "bin", "brew") + " shellenv")
+}
+
+// updatePath puts Homebrew's bin directory on PATH for mycli itself and loads
+// `brew shellenv` from a managed block of the user's rc file, creating the
+// file if there is none yet.
+func updatePath(ctx context.Context, iostream *iostreams.IOStreams) error {
+	prefix := detectBrewPrefix()
+	homebrewPath := filepath.Join(prefix, "bin")
+
+	if err := prependPath(homebrewPath); err != nil {
+		return err
+	}
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("failed to get home directory: %v", err)
 	}
 
-	shellConfigFiles := []string{".zshrc"}
-	updatedFile := ""
-	for _, file := range shellConfigFiles {
-		configPath := filepath.Join(homeDir, file)
-		if _, err := os.Stat(configPath); err == nil {
-			content, err := os.ReadFile(configPath)
-			if err != nil {
-				return fmt.Errorf("failed to read %s: %v", file, err)
-			}
-
-			if !strings.Contains(string(content), fmt.Sprintf("export PATH=\"%s:$PATH\"", homebrewPath)) {
-				newContent := fmt.Sprintf("%s\nexport PATH=\"%s:$PATH\"\n", string(content), homebrewPath)
-				if err := os.WriteFile(configPath, []byte(newContent), 0644); err != nil {
-					return fmt.Errorf("failed to update %s: %v", file, err)
-				}
-				fmt.Fprintf(iostream.Out, "Updated %s with Homebrew path\n", file)
-				return nil // Exit after updating the first file that needs it
-			}
-		}
+	file, shellenvLine := shellenvConfig(prefix)
+	configPath := filepath.Join(homeDir, file)
+	// A missing rc file is created, along with its directory for fish, by
+	// SetManagedBlock.
+	content, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %v", file, err)
 	}
 
-	if updatedFile != "" {
-		// Attempt to source the updated file
-		cmd := execCommandContext(ctx, "zsh", "-c", fmt.Sprintf("source %s", updatedFile))
-		cmd.Stdout = iostream.Out
-		cmd.Stderr = iostream.ErrOut
-		if err := cmd.Run(); err != nil {
-			fmt.Fprintf(iostream.ErrOut, "Warning: Failed to source updated configuration: %v\n", err)
-			fmt.Fprintln(iostream.Out, "You may need to restart your terminal or manually source your shell configuration file.")
-		} else {
-			fmt.Fprintln(iostream.Out, "Shell configuration has been sourced.")
-		}
+	// Older versions of mycli appended the line, or a plain PATH export, outside
+	// a managed block; either is just as good, so don't add a second one.
+	unmanaged := utils.StripBlocks(string(content))
+	legacyLine := utils.DetectShell().PathLine(homebrewPath, true)
+	if strings.Contains(unmanaged, shellenvLine) || strings.Contains(unmanaged, legacyLine) {
+		return nil
 	}
 
+	changed, err := utils.SetManagedBlock(configPath, "homebrew", shellenvLine)
+	if err != nil {
+		return err
+	}
+	if changed {
+		fmt.Fprintf(iostream.Out, "Updated %s with Homebrew path\n", file)
+	}
 	return nil
 }
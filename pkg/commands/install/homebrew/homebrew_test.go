@@ -3,10 +3,15 @@ package homebrew
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/XiaoConstantine/mycli/pkg/iostreams"
@@ -129,6 +134,11 @@ func TestNewCmdHomeBrew(t *testing.T) {
 		},
 	}
 
+	oldGoos := goos
+	defer func() { goos = oldGoos }()
+	goos = "darwin"
+	t.Setenv("HOME", t.TempDir())
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup
@@ -187,6 +197,158 @@ func TestNewCmdHomeBrew(t *testing.T) {
 	}
 }
 
+func TestNewCmdHomeBrewLinux(t *testing.T) {
+	oldGoos, oldLookPath, oldExecCommandContext := goos, lookPath, execCommandContext
+	defer func() { goos, lookPath, execCommandContext = oldGoos, oldLookPath, oldExecCommandContext }()
+	goos = "linux"
+	lookPath = func(file string) (string, error) {
+		if file == "apt-get" {
+			return "/usr/bin/apt-get", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	mockCmd := &mockCommandContext{}
+	execCommandContext = mockCmd.CommandContext
+	mockCmd.On("CommandContext", mock.Anything, "which", []string{"brew"}).Return(exec.Command("false"))
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "sudo apt-get update && sudo apt-get install -y build-essential procps curl file git"}).
+		Return(exec.Command("true"))
+	mockCmd.On("CommandContext", mock.Anything, "bash", mock.Anything).Return(exec.Command("true"))
+
+	mockUtil := &mockUtils{}
+	mockUtil.On("GetCurrentUser").Return(&user.User{Username: "testuser"}, nil)
+	mockUtil.On("IsAdmin", mock.Anything, mock.Anything).Return(true)
+
+	t.Setenv("HOME", t.TempDir())
+	ios, _, out, _ := iostreams.Test()
+	cmd := NewCmdHomeBrew(ios, mockUtil, utils.NewStatsCollector())
+	err := cmd.RunE(cmd, []string{})
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Installing Homebrew prerequisites...")
+	assert.Contains(t, out.String(), "/home/linuxbrew/.linuxbrew")
+	mockCmd.AssertExpectations(t)
+}
+
+func TestNewCmdHomeBrewUserPrefix(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/zsh")
+	t.Setenv("PATH", os.Getenv("PATH"))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".zshrc"), []byte("# zshrc\n"), 0644))
+	prefix := filepath.Join(home, ".homebrew")
+
+	tarball := []byte("brew tarball")
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		w.Write(tarball)
+	}))
+	defer server.Close()
+	oldTarballURL := brewTarballURL
+	brewTarballURL = server.URL + "/brew/%s.tar.gz"
+	defer func() { brewTarballURL = oldTarballURL }()
+
+	executed := []string{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if name == "which" {
+			return exec.Command("false")
+		}
+		executed = append(executed, args[len(args)-1])
+		return exec.Command("true")
+	}
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	mockUtil := &mockUtils{}
+	mockUtil.On("GetCurrentUser").Return(&user.User{Username: "contractor"}, nil)
+	mockUtil.On("IsAdmin", mock.Anything, mock.Anything).Return(false)
+
+	ios, _, out, _ := iostreams.Test()
+	statsCollector := utils.NewStatsCollector()
+	cmd := NewCmdHomeBrew(ios, mockUtil, statsCollector)
+	cmd.SetArgs([]string{"--user", "--sha256", utils.SHA256Hex(tarball)})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, []string{"/brew/" + brewVersion + ".tar.gz"}, requested)
+	require.Len(t, executed, 2)
+	assert.Regexp(t, fmt.Sprintf(`^mkdir -p '%s' && tar xzf '.*mycli-homebrew-.*\.tar\.gz' --strip-components 1 -C '%s'$`, regexp.QuoteMeta(prefix), regexp.QuoteMeta(prefix)), executed[0])
+	assert.Equal(t, "brew update --force --quiet", executed[1])
+	assert.Contains(t, out.String(), "no admin rights needed")
+	assert.True(t, strings.HasPrefix(os.Getenv("PATH"), filepath.Join(prefix, "bin")+":"))
+
+	state, err := utils.LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, prefix, state.HomebrewPrefix)
+
+	zshrc, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+	require.NoError(t, err)
+	assert.Contains(t, string(zshrc), fmt.Sprintf(`eval "$(%s/bin/brew shellenv)"`, prefix))
+
+	stats := statsCollector.GetStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "success", stats[0].Status)
+}
+
+func TestInstallToUserPrefixChecksum(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SHELL", "/bin/zsh")
+	t.Setenv("PATH", os.Getenv("PATH"))
+	tarball := []byte("brew tarball")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tarball)
+	}))
+	defer server.Close()
+	oldTarballURL := brewTarballURL
+	brewTarballURL = server.URL + "/brew/%s.tar.gz"
+	defer func() { brewTarballURL = oldTarballURL }()
+
+	var executed []string
+	oldExecCommandContext := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		executed = append(executed, args[len(args)-1])
+		return exec.Command("true")
+	}
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	ios, _, _, errOut := iostreams.Test()
+	prefix := filepath.Join(t.TempDir(), "brew")
+	err := installToUserPrefix(context.Background(), ios, prefix, "4.3.0", strings.Repeat("0", 64))
+	assert.ErrorContains(t, err, "checksum mismatch")
+	assert.Empty(t, executed, "a tarball that doesn't match must not be unpacked")
+
+	require.NoError(t, installToUserPrefix(context.Background(), ios, prefix, "4.3.0", ""))
+	assert.Contains(t, errOut.String(), "its SHA-256 is "+utils.SHA256Hex(tarball))
+	assert.NotEmpty(t, executed)
+}
+
+func TestShellenvConfig(t *testing.T) {
+	oldGoos := goos
+	defer func() { goos = oldGoos }()
+
+	tests := []struct {
+		goos     string
+		shell    string
+		wantFile string
+		wantLine string
+	}{
+		{"darwin", "/bin/zsh", ".zshrc", `eval "$(/opt/homebrew/bin/brew shellenv)"`},
+		{"darwin", "/bin/bash", ".bash_profile", `eval "$(/opt/homebrew/bin/brew shellenv)"`},
+		{"linux", "/usr/bin/bash", ".bashrc", `eval "$(/opt/homebrew/bin/brew shellenv)"`},
+		{"linux", "/usr/bin/fish", filepath.Join(".config", "fish", "config.fish"), "/opt/homebrew/bin/brew shellenv | source"},
+		{"linux", "", ".zshrc", `eval "$(/opt/homebrew/bin/brew shellenv)"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+tt.shell, func(t *testing.T) {
+			goos = tt.goos
+			t.Setenv("SHELL", tt.shell)
+			file, line := shellenvConfig("/opt/homebrew")
+			assert.Equal(t, tt.wantFile, file)
+			assert.Equal(t, tt.wantLine, line)
+		})
+	}
+}
+
 func TestUpdatePath(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "homebrew_test")
 	require.NoError(t, err)
@@ -195,6 +357,11 @@ func TestUpdatePath(t *testing.T) {
 	origHome := os.Getenv("HOME")
 	defer os.Setenv("HOME", origHome)
 	os.Setenv("HOME", tempDir)
+	t.Setenv("SHELL", "/bin/zsh")
+
+	oldDetectBrewPrefix := detectBrewPrefix
+	defer func() { detectBrewPrefix = oldDetectBrewPrefix }()
+	detectBrewPrefix = func() string { return "/opt/homebrew" }
 
 	tests := []struct {
 		name           string
@@ -216,12 +383,12 @@ func TestUpdatePath(t *testing.T) {
 			expectChange:   true,
 		},
 		{
-			name: "No config file to update",
+			name: "Missing .zshrc is created",
 			setupFunc: func() string {
 				return ""
 			},
-			expectedOutput: "",
-			expectChange:   false,
+			expectedOutput: "Updated .zshrc with Homebrew path\n",
+			expectChange:   true,
 		},
 		{
 			name: "Config already updated",
@@ -235,6 +402,18 @@ func TestUpdatePath(t *testing.T) {
 			expectedOutput: "",
 			expectChange:   false,
 		},
+		{
+			name: "Shellenv already present",
+			setupFunc: func() string {
+				content := "eval \"$(/opt/homebrew/bin/brew shellenv)\"\n"
+				if err := os.WriteFile(filepath.Join(tempDir, ".zshrc"), []byte(content), 0644); err != nil {
+					return ""
+				}
+				return content
+			},
+			expectedOutput: "",
+			expectChange:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,166 @@
+package homebrew
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+)
+
+// languageBackend installs, inspects and upgrades tools through a language
+// package manager instead of Homebrew. The tool's name is the package name.
+type languageBackend struct {
+	// install returns the command installing name, at version when set.
+	install func(name, version string) string
+	// upgrade returns the command upgrading name to its latest version.
+	upgrade func(name string) string
+	// installed returns the installed version of name, or "" if it isn't.
+	installed func(ctx context.Context, name string) (string, error)
+	// latest returns the latest version of name the registry offers.
+	latest func(ctx context.Context, name string) (string, error)
+}
+
+// languageBackends are the install methods besides brew and cask.
+var languageBackends = map[string]languageBackend{
+	"npm": {
+		install: func(name, version string) string {
+			if version != "" {
+				name += "@" + version
+			}
+			return "npm install -g " + shellQuote(name)
+		},
+		upgrade: func(name string) string {
+			return "npm install -g " + shellQuote(name+"@latest")
+		},
+		installed: npmInstalled,
+		latest: func(ctx context.Context, name string) (string, error) {
+			return commandOutput("npm view "+shellQuote(name)+" version", ctx)
+		},
+	},
+	"pipx": {
+		install: func(name, version string) string {
+			if version != "" {
+				name += "==" + version
+			}
+			return "pipx install " + shellQuote(name)
+		},
+		upgrade: func(name string) string {
+			return "pipx upgrade " + shellQuote(name)
+		},
+		installed: pipxInstalled,
+		latest:    pipLatest,
+	},
+	"cargo": {
+		install: func(name, version string) string {
+			command := "cargo install " + shellQuote(name)
+			if version != "" {
+				command += " --version " + shellQuote(version)
+			}
+			return command
+		},
+		// cargo install replaces an installed crate only when a newer
+		// version is available.
+		upgrade: func(name string) string {
+			return "cargo install " + shellQuote(name)
+		},
+		installed: cargoInstalled,
+		latest:    cargoLatest,
+	},
+}
+
+// languageBackendFor returns the language backend of tool, if it uses one.
+func languageBackendFor(tool utils.Tool) (languageBackend, bool) {
+	if isCustom(tool) {
+		return languageBackend{}, false
+	}
+	backend, ok := languageBackends[tool.Method]
+	return backend, ok
+}
+
+func npmInstalled(ctx context.Context, name string) (string, error) {
+	// npm ls exits non-zero when the package is missing but still prints JSON.
+	out, _ := commandOutput("npm ls -g --depth=0 --json "+shellQuote(name), ctx)
+	var list struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return "", fmt.Errorf("failed to parse npm ls output: %v", err)
+	}
+	return list.Dependencies[name].Version, nil
+}
+
+func pipxInstalled(ctx context.Context, name string) (string, error) {
+	out, err := commandOutput("pipx list --json", ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list pipx packages: %v", err)
+	}
+	var list struct {
+		Venvs map[string]struct {
+			Metadata struct {
+				MainPackage struct {
+					PackageVersion string `json:"package_version"`
+				} `json:"main_package"`
+			} `json:"metadata"`
+		} `json:"venvs"`
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return "", fmt.Errorf("failed to parse pipx list output: %v", err)
+	}
+	return list.Venvs[name].Metadata.MainPackage.PackageVersion, nil
+}
+
+// pipIndexVersion matches the first line of `pip index versions`, e.g.
+// "black (24.4.2)".
+var pipIndexVersion = regexp.MustCompile(`^\S+ \(([^)]+)\)`)
+
+func pipLatest(ctx context.Context, name string) (string, error) {
+	out, err := commandOutput("python3 -m pip index versions "+shellQuote(name), ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s on PyPI: %v", name, err)
+	}
+	m := pipIndexVersion.FindStringSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("unexpected pip index output for %s", name)
+	}
+	return m[1], nil
+}
+
+// cargoInstalled reads `cargo install --list`, which prints a line like
+// "ripgrep v14.1.0:" per crate followed by its binaries.
+func cargoInstalled(ctx context.Context, name string) (string, error) {
+	out, err := commandOutput("cargo install --list", ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list cargo crates: %v", err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(strings.TrimSuffix(line, ":"))
+		if len(fields) >= 2 && fields[0] == name && strings.HasPrefix(fields[1], "v") {
+			return strings.TrimPrefix(fields[1], "v"), nil
+		}
+	}
+	return "", nil
+}
+
+// cargoLatest reads `cargo search`, which prints lines like
+// `ripgrep = "14.1.0"    # description` for the best matches.
+func cargoLatest(ctx context.Context, name string) (string, error) {
+	out, err := commandOutput("cargo search --limit 5 "+shellQuote(name), ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s on crates.io: %v", name, err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		crate, rest, ok := strings.Cut(line, " = ")
+		if !ok || crate != name {
+			continue
+		}
+		if version, _, ok := strings.Cut(strings.TrimPrefix(rest, `"`), `"`); ok {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found on crates.io", name)
+}
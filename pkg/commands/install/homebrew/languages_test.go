@@ -0,0 +1,142 @@
+package homebrew
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCommands makes commands run through sh print the output given for
+// them, fail when none is given, and records them.
+func stubCommands(t *testing.T, outputs map[string]string) *[]string {
+	executed := []string{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		command := strings.Join(args[1:], " ")
+		executed = append(executed, command)
+		out, ok := outputs[command]
+		if !ok {
+			return exec.Command("false")
+		}
+		return exec.Command("printf", "%s", out)
+	}
+	t.Cleanup(func() { execCommandContext = oldExecCommandContext })
+	return &executed
+}
+
+func TestLanguageBackendCommands(t *testing.T) {
+	tests := []struct {
+		method, version string
+		install         string
+		upgrade         string
+	}{
+		{"npm", "", "npm install -g 'prettier'", "npm install -g 'prettier@latest'"},
+		{"npm", "3.3.0", "npm install -g 'prettier@3.3.0'", "npm install -g 'prettier@latest'"},
+		{"pipx", "", "pipx install 'prettier'", "pipx upgrade 'prettier'"},
+		{"pipx", "1.0", "pipx install 'prettier==1.0'", "pipx upgrade 'prettier'"},
+		{"cargo", "", "cargo install 'prettier'", "cargo install 'prettier'"},
+		{"cargo", "0.1.2", "cargo install 'prettier' --version '0.1.2'", "cargo install 'prettier'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.version, func(t *testing.T) {
+			backend := languageBackends[tt.method]
+			assert.Equal(t, tt.install, backend.install("prettier", tt.version))
+			assert.Equal(t, tt.upgrade, backend.upgrade("prettier"))
+		})
+	}
+
+	_, ok := languageBackendFor(utils.Tool{Name: "x", Method: "npm", InstallCommand: "make install"})
+	assert.False(t, ok, "an install_command takes precedence over the method")
+}
+
+func TestLanguageToolVersions(t *testing.T) {
+	stubCommands(t, map[string]string{
+		"npm ls -g --depth=0 --json 'prettier'": `{"dependencies":{"prettier":{"version":"3.2.0"}}}`,
+		"npm view 'prettier' version":           "3.3.3\n",
+		"npm ls -g --depth=0 --json 'eslint'":   `{}`,
+		"pipx list --json":                      `{"venvs":{"black":{"metadata":{"main_package":{"package_version":"24.4.2"}}}}}`,
+		"python3 -m pip index versions 'black'": "black (24.4.2)\nAvailable versions: 24.4.2, 24.4.1\n",
+		"cargo install --list":                  "ripgrep v14.0.0:\n    rg\nbat v0.24.0:\n    bat\n",
+		"cargo search --limit 5 'ripgrep'":      "ripgrep = \"14.1.0\"    # fast grep\nripgrep_all = \"0.10.6\"    # rga\n",
+		"cargo search --limit 5 'bat'":          "bat = \"0.24.0\"    # cat clone\n",
+	})
+
+	tools := []utils.Tool{
+		{Name: "prettier", Method: "npm"},
+		{Name: "eslint", Method: "npm"},
+		{Name: "black", Method: "pipx"},
+		{Name: "ripgrep", Method: "cargo"},
+		{Name: "bat", Method: "cargo", Version: "0.24.0"},
+		{Name: "ruff", Method: "pipx"},
+	}
+	// ruff isn't in pipx list, so it is reported as not installed.
+	versions, err := ToolVersions(context.Background(), tools)
+	require.NoError(t, err)
+
+	got := map[string]ToolVersion{}
+	for _, v := range versions {
+		got[v.Name] = v
+	}
+	assert.Equal(t, ToolVersion{Name: "prettier", Backend: "npm", Installed: "3.2.0", Latest: "3.3.3", Status: StatusOutdated}, got["prettier"])
+	assert.Equal(t, StatusNotInstalled, got["eslint"].Status)
+	assert.Equal(t, ToolVersion{Name: "black", Backend: "pipx", Installed: "24.4.2", Latest: "24.4.2", Status: StatusUpToDate}, got["black"])
+	assert.Equal(t, ToolVersion{Name: "ripgrep", Backend: "cargo", Installed: "14.0.0", Latest: "14.1.0", Status: StatusOutdated}, got["ripgrep"])
+	assert.Equal(t, StatusPinned, got["bat"].Status)
+	assert.Equal(t, StatusNotInstalled, got["ruff"].Status)
+}
+
+func TestLanguageToolVersionUnknownOnError(t *testing.T) {
+	stubCommands(t, map[string]string{
+		"pipx list --json": `{"venvs":{"black":{"metadata":{"main_package":{"package_version":"24.4.2"}}}}}`,
+	})
+	versions, err := ToolVersions(context.Background(), []utils.Tool{{Name: "black", Method: "pipx"}})
+	require.NoError(t, err)
+	assert.Equal(t, StatusUnknown, versions[0].Status)
+	assert.Equal(t, "24.4.2", versions[0].Installed)
+}
+
+func TestInstallLanguageTools(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SHELL", "/bin/zsh")
+	executed := stubCommands(t, map[string]string{
+		"npm install -g 'prettier@3.3.0'": "",
+		"cargo install 'ripgrep'":         "",
+		"brew install jq":                 "",
+	})
+
+	ios, _, out, _ := iostreams.Test()
+	config := &utils.ToolConfig{Tools: []utils.Tool{
+		{Name: "prettier", Method: "npm", Version: "3.3.0"},
+		{Name: "ripgrep", Method: "cargo"},
+		{Name: "jq"},
+	}}
+	stats, err := InstallToolsFromConfig(ios, config, context.Background(), false)
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, []string{"npm install -g 'prettier@3.3.0'", "cargo install 'ripgrep'", "brew install jq"}, *executed)
+	assert.Contains(t, out.String(), "Installing prettier using npm with npm install -g 'prettier@3.3.0'...")
+}
+
+func TestUpgradeLanguageTools(t *testing.T) {
+	executed := stubCommands(t, map[string]string{
+		"brew upgrade jq":                  "",
+		"npm install -g 'prettier@latest'": "",
+		"brew upgrade git":                 "",
+	})
+
+	ios, _, _, errOut := iostreams.Test()
+	tools := []utils.Tool{{Name: "jq"}, {Name: "prettier", Method: "npm"}, {Name: "git"}, {Name: "black", Method: "pipx"}}
+	stats, err := UpgradeTools(ios, tools, context.Background())
+	require.Error(t, err)
+	assert.Equal(t, []string{"brew upgrade jq", "npm install -g 'prettier@latest'", "brew upgrade git", "pipx upgrade 'black'"}, *executed)
+	require.Len(t, stats, 4)
+	assert.Equal(t, "success", stats[1].Status)
+	assert.Equal(t, "error", stats[3].Status)
+	assert.Contains(t, errOut.String(), "Failed to upgrade black")
+}
@@ -0,0 +1,316 @@
+package homebrew
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var goarch = runtime.GOARCH
+
+// gitHubAPIURL is where releases are looked up. It is a variable so tests can
+// point it at a local server.
+var gitHubAPIURL = "https://api.github.com"
+
+// releaseMethod installs a tool's binary from the GitHub releases of its repo.
+const releaseMethod = "github-release"
+
+// githubRelease is the subset of the GitHub releases API that we care about.
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// Words release assets use for each OS and architecture in their names.
+var (
+	osAliases   = map[string][]string{"darwin": {"darwin", "macos", "apple", "osx"}, "linux": {"linux"}}
+	archAliases = map[string][]string{"amd64": {"amd64", "x86_64", "x64"}, "arm64": {"arm64", "aarch64"}}
+)
+
+// skippedAssets are suffixes of release assets that aren't the tool itself:
+// checksums, signatures and packages for other installers.
+var skippedAssets = []string{
+	".sha256", ".sha256sum", ".sha512", ".asc", ".sig", ".pem", ".sbom", ".json", ".txt",
+	".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg", ".exe", ".tar.xz", ".tar.bz2", ".tar.zst",
+}
+
+// isRelease reports whether tool is installed from GitHub releases.
+func isRelease(tool utils.Tool) bool {
+	return tool.Method == releaseMethod && !isCustom(tool)
+}
+
+// getRelease looks up the release of repo tagged tag, or its latest release
+// when tag is empty. Lookups go through utils.Download, so they use the
+// configured credentials and work offline once cached.
+var getRelease = func(ctx context.Context, repo, tag string) (*githubRelease, error) {
+	rawURL := fmt.Sprintf("%s/repos/%s/releases/latest", gitHubAPIURL, repo)
+	if tag != "" {
+		rawURL = fmt.Sprintf("%s/repos/%s/releases/tags/%s", gitHubAPIURL, repo, url.PathEscape(tag))
+	}
+	body, err := utils.Download(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var release githubRelease
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release of %s: %v", repo, err)
+	}
+	return &release, nil
+}
+
+// installRelease installs tool's binary from the release tagged tag, or the
+// latest release when tag is empty, into ~/.mycli/bin and records the tag
+// installed in the state file.
+func installRelease(iostream *iostreams.IOStreams, tool utils.Tool, tag string, ctx context.Context) error {
+	release, err := getRelease(ctx, tool.Repo, tag)
+	if err != nil {
+		return err
+	}
+	name, assetURL, err := releaseAsset(release, tool.Asset)
+	if err != nil {
+		return fmt.Errorf("%s %s: %v", tool.Repo, release.TagName, err)
+	}
+	content, err := utils.Download(ctx, assetURL)
+	if err != nil {
+		return err
+	}
+	binary, err := extractBinary(name, content, releaseBinary(tool))
+	if err != nil {
+		return err
+	}
+
+	dir, err := releaseBinDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	target := filepath.Join(dir, releaseBinary(tool))
+	err = utils.WriteAtomic(target, 0755, func(f *os.File) error {
+		if _, err := f.Write(binary); err != nil {
+			return err
+		}
+		return f.Chmod(0755)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to install %s: %v", target, err)
+	}
+	fmt.Fprintf(iostream.Out, "Installed %s %s to %s\n", tool.Name, release.TagName, target)
+	if err := ensureReleaseBinOnPath(iostream, dir); err != nil {
+		fmt.Fprintf(iostream.ErrOut, "Failed to add %s to your PATH: %v\n", dir, err)
+	}
+
+	state, err := utils.LoadState()
+	if err != nil {
+		return err
+	}
+	if state.Releases == nil {
+		state.Releases = make(map[string]string)
+	}
+	state.Releases[tool.Name] = release.TagName
+	return utils.SaveState(state)
+}
+
+// releaseAsset picks the asset of release to install: the one matching
+// pattern when set, or else the first one naming this OS and architecture.
+func releaseAsset(release *githubRelease, pattern string) (name, assetURL string, err error) {
+	for _, asset := range release.Assets {
+		lower := strings.ToLower(asset.Name)
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, asset.Name); ok {
+				return asset.Name, asset.URL, nil
+			}
+			continue
+		}
+		if hasAnySuffix(lower, skippedAssets) || !containsAny(lower, osAliases[goos]) || !containsAny(lower, archAliases[goarch]) {
+			continue
+		}
+		return asset.Name, asset.URL, nil
+	}
+	if pattern != "" {
+		return "", "", fmt.Errorf("no release asset matches %q", pattern)
+	}
+	return "", "", fmt.Errorf("no release asset for %s/%s, set asset to choose one", goos, goarch)
+}
+
+// extractBinary returns the file named binary from a .tar.gz or .zip asset.
+// Any other asset is taken to be the binary itself.
+func extractBinary(asset string, content []byte, binary string) ([]byte, error) {
+	lower := strings.ToLower(asset)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", asset, err)
+		}
+		defer gz.Close()
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", asset, err)
+			}
+			if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binary {
+				return io.ReadAll(tr)
+			}
+		}
+	case strings.HasSuffix(lower, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", asset, err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().Mode().IsRegular() && path.Base(f.Name) == binary {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, fmt.Errorf("failed to read %s: %v", asset, err)
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}
+		}
+	default:
+		return content, nil
+	}
+	return nil, fmt.Errorf("%s not found in %s, set binary to its name", binary, asset)
+}
+
+// releaseVersion reports the installed tag of tool and the tag of its latest
+// release. Without a network, or for a repo that can't be looked up, the
+// status is unknown rather than failing the whole lookup.
+func releaseVersion(ctx context.Context, tool utils.Tool) ToolVersion {
+	span, ctx := tracer.StartSpanFromContext(ctx, "tool_version_"+releaseMethod)
+	defer span.Finish()
+
+	v := ToolVersion{Backend: releaseMethod}
+	var err error
+	if v.Installed, err = installedRelease(tool); err != nil || v.Installed == "" {
+		if err != nil {
+			span.SetTag("error", err)
+			v.Status = StatusUnknown
+		}
+		return v
+	}
+	release, err := getRelease(ctx, tool.Repo, "")
+	if err != nil {
+		span.SetTag("error", err)
+		v.Status = StatusUnknown
+		return v
+	}
+	v.Latest = release.TagName
+	return v
+}
+
+// installedRelease returns the tag of tool recorded in the state file, or ""
+// when its binary isn't in ~/.mycli/bin.
+func installedRelease(tool utils.Tool) (string, error) {
+	dir, err := releaseBinDir()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(dir, releaseBinary(tool))); os.IsNotExist(err) {
+		return "", nil
+	}
+	state, err := utils.LoadState()
+	if err != nil {
+		return "", err
+	}
+	return state.Releases[tool.Name], nil
+}
+
+func upgradeReleaseTool(iostream *iostreams.IOStreams, tool utils.Tool, ctx context.Context) (*utils.Stats, error) {
+	cs := iostream.ColorScheme()
+	span, ctx := tracer.StartSpanFromContext(ctx, fmt.Sprintf("upgrade_%s", tool.Name))
+	defer span.Finish()
+	startTime := time.Now()
+	stat := &utils.Stats{Name: tool.Name, Operation: "Upgrade", Status: "success"}
+
+	fmt.Fprintf(iostream.Out, "Upgrading %s from the latest GitHub release of %s...\n", tool.Name, tool.Repo)
+	err := installRelease(iostream, tool, "", ctx)
+	stat.Duration = time.Since(startTime)
+	if err != nil {
+		fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to upgrade %s: %v\n"), tool.Name, err)
+		stat.Status = "error"
+		span.SetTag("status", "failed")
+		span.SetTag("error", err)
+		return stat, err
+	}
+	span.SetTag("status", "success")
+	return stat, nil
+}
+
+// releaseBinDir is ~/.mycli/bin, the directory `mycli update` installs mycli
+// into and puts on PATH.
+func releaseBinDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(home, ".mycli", "bin"), nil
+}
+
+// ensureReleaseBinOnPath keeps dir on PATH in the same managed block of the
+// shell rc file that `mycli update` writes.
+func ensureReleaseBinOnPath(iostream *iostreams.IOStreams, dir string) error {
+	rc, err := utils.DefaultShellRC()
+	if err != nil {
+		return err
+	}
+	changed, err := utils.SetManagedBlock(rc, "mycli", utils.DetectShell().PathLine(dir, false))
+	if err != nil {
+		return err
+	}
+	if changed {
+		fmt.Fprintf(iostream.Out, "Added %s to your PATH in %s\n", dir, rc)
+	}
+	return nil
+}
+
+func releaseBinary(tool utils.Tool) string {
+	if tool.Binary != "" {
+		return tool.Binary
+	}
+	return path.Base(tool.Name)
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(s string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+	return false
+}
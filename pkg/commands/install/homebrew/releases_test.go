@@ -0,0 +1,196 @@
+package homebrew
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestReleaseAsset(t *testing.T) {
+	oldGoos, oldGoarch := goos, goarch
+	goos, goarch = "darwin", "arm64"
+	defer func() { goos, goarch = oldGoos, oldGoarch }()
+
+	release := &githubRelease{TagName: "v1.0.0"}
+	for _, name := range []string{
+		"tool_1.0.0_linux_arm64.tar.gz",
+		"tool_1.0.0_darwin_arm64.tar.gz.sha256",
+		"tool_1.0.0_darwin_amd64.tar.gz",
+		"tool_1.0.0_darwin_arm64.tar.gz",
+		"tool-aarch64-apple-darwin.zip",
+	} {
+		release.Assets = append(release.Assets, struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		}{name, "https://example.com/" + name})
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{name: "this OS and architecture", want: "tool_1.0.0_darwin_arm64.tar.gz"},
+		{name: "pattern", pattern: "*apple-darwin.zip", want: "tool-aarch64-apple-darwin.zip"},
+		{name: "no match", pattern: "*.deb", wantErr: `no release asset matches "*.deb"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, assetURL, err := releaseAsset(release, tt.pattern)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, name)
+			assert.Equal(t, "https://example.com/"+tt.want, assetURL)
+		})
+	}
+
+	goarch = "386"
+	_, _, err := releaseAsset(release, "")
+	assert.EqualError(t, err, "no release asset for darwin/386, set asset to choose one")
+}
+
+func TestExtractBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		asset   string
+		content []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "tarball", asset: "tool.tar.gz", content: tarGz(t, map[string]string{"tool-1.0/README.md": "docs", "tool-1.0/tool": "binary"}), want: "binary"},
+		{name: "zip", asset: "tool.zip", content: zipped(t, map[string]string{"bin/tool": "binary"}), want: "binary"},
+		{name: "plain binary", asset: "tool-darwin-arm64", content: []byte("binary"), want: "binary"},
+		{name: "missing from archive", asset: "tool.tgz", content: tarGz(t, map[string]string{"other": "x"}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractBinary(tt.asset, tt.content, "tool")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestInstallAndUpgradeRelease(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/zsh")
+
+	oldGoos, oldGoarch := goos, goarch
+	goos, goarch = "linux", "amd64"
+	defer func() { goos, goarch = oldGoos, oldGoarch }()
+
+	var server *httptest.Server
+	release := func(tag string) string {
+		return fmt.Sprintf(`{"tag_name":%q,"assets":[{"name":"tool_%s_linux_x86_64.tar.gz","browser_download_url":"%s/download/%s"}]}`, tag, tag, server.URL, tag)
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/tool/releases/tags/v1.0.0":
+			fmt.Fprint(w, release("v1.0.0"))
+		case "/repos/owner/tool/releases/latest":
+			fmt.Fprint(w, release("v1.1.0"))
+		case "/download/v1.0.0":
+			w.Write(tarGz(t, map[string]string{"tool": "v1.0.0 binary"}))
+		case "/download/v1.1.0":
+			w.Write(tarGz(t, map[string]string{"tool": "v1.1.0 binary"}))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	oldAPIURL := gitHubAPIURL
+	gitHubAPIURL = server.URL
+	defer func() { gitHubAPIURL = oldAPIURL }()
+
+	ios, _, out, _ := iostreams.Test()
+	tool := utils.Tool{Name: "tool", Method: "github-release", Repo: "owner/tool", Version: "v1.0.0"}
+	versions, err := ToolVersions(context.Background(), []utils.Tool{tool})
+	require.NoError(t, err)
+	assert.Equal(t, StatusNotInstalled, versions[0].Status)
+
+	stats, err := InstallToolsFromConfig(ios, &utils.ToolConfig{Tools: []utils.Tool{tool}}, context.Background(), false)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "success", stats[0].Status)
+	binary := filepath.Join(home, ".mycli", "bin", "tool")
+	content, err := os.ReadFile(binary)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0 binary", string(content))
+	info, err := os.Stat(binary)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	assert.Contains(t, out.String(), "Installed tool v1.0.0 to "+binary)
+	rc, err := os.ReadFile(filepath.Join(home, ".zshrc"))
+	require.NoError(t, err)
+	assert.Contains(t, string(rc), filepath.Join(home, ".mycli", "bin"))
+
+	versions, err = ToolVersions(context.Background(), []utils.Tool{tool})
+	require.NoError(t, err)
+	assert.Equal(t, ToolVersion{Name: "tool", Backend: "github-release", Installed: "v1.0.0", Latest: "v1.1.0", Pinned: true, Status: StatusPinned}, versions[0])
+
+	tool.Version = ""
+	versions, err = ToolVersions(context.Background(), []utils.Tool{tool})
+	require.NoError(t, err)
+	assert.Equal(t, StatusOutdated, versions[0].Status)
+
+	stats, err = UpgradeTools(ios, []utils.Tool{tool}, context.Background())
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "Upgrade", stats[0].Operation)
+	content, err = os.ReadFile(binary)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0 binary", string(content))
+
+	versions, err = ToolVersions(context.Background(), []utils.Tool{tool})
+	require.NoError(t, err)
+	assert.Equal(t, StatusUpToDate, versions[0].Status)
+}
@@ -0,0 +1,357 @@
+package homebrew
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var lookPath = exec.LookPath
+
+// Locations used for apt and dnf repositories. They are variables so tests can
+// point them at a temporary directory.
+var (
+	aptKeyringDir = "/etc/apt/keyrings"
+	aptSourcesDir = "/etc/apt/sources.list.d"
+	dnfReposDir   = "/etc/yum.repos.d"
+)
+
+// ApplySources makes sure every tap and repository declared in the config is
+// configured before any tool is installed.
+//
+// It is safe to run repeatedly: taps that are already tapped and repository
+// files whose content is unchanged are left alone, and package metadata is only
+// refreshed when something was actually added.
+func ApplySources(iostream *iostreams.IOStreams, sources utils.Sources, ctx context.Context) ([]*utils.Stats, error) {
+	var stats []*utils.Stats
+
+	span, ctx := tracer.StartSpanFromContext(ctx, "apply_sources")
+	defer span.Finish()
+
+	if len(sources.Taps) > 0 {
+		tapped, err := installedTaps(ctx)
+		if err != nil {
+			span.SetTag("error", err)
+			return stats, fmt.Errorf("failed to list brew taps: %v", err)
+		}
+		for _, tap := range sources.Taps {
+			tap := tap
+			stat, err := runSourceStep("tap "+tap.Name, "Add source", func() error {
+				if tapped[tap.Name] {
+					fmt.Fprintf(iostream.Out, "Tap %s is already present.\n", tap.Name)
+					return nil
+				}
+				fmt.Fprintf(iostream.Out, "Tapping %s...\n", tap.Name)
+				return executeCommand(tapCommand(tap), ctx)
+			})
+			stats = append(stats, stat)
+			if err != nil {
+				return stats, sourceError(iostream, span, stat.Name, err)
+			}
+		}
+	}
+
+	if len(sources.Apt) > 0 {
+		aptStats, err := applyAptSources(iostream, sources.Apt, ctx)
+		stats = append(stats, aptStats...)
+		if err != nil {
+			return stats, sourceError(iostream, span, aptStats[len(aptStats)-1].Name, err)
+		}
+	}
+
+	if len(sources.Dnf) > 0 {
+		dnfStats, err := applyDnfSources(iostream, sources.Dnf, ctx)
+		stats = append(stats, dnfStats...)
+		if err != nil {
+			return stats, sourceError(iostream, span, dnfStats[len(dnfStats)-1].Name, err)
+		}
+	}
+
+	span.SetTag("status", "success")
+	return stats, nil
+}
+
+// RemoveSources undoes ApplySources: it untaps the declared taps and deletes the
+// repository and keyring files mycli created for apt and dnf.
+func RemoveSources(iostream *iostreams.IOStreams, sources utils.Sources, ctx context.Context) ([]*utils.Stats, error) {
+	var stats []*utils.Stats
+
+	span, ctx := tracer.StartSpanFromContext(ctx, "remove_sources")
+	defer span.Finish()
+
+	if len(sources.Taps) > 0 {
+		tapped, err := installedTaps(ctx)
+		if err != nil {
+			span.SetTag("error", err)
+			return stats, fmt.Errorf("failed to list brew taps: %v", err)
+		}
+		for _, tap := range sources.Taps {
+			tap := tap
+			stat, err := runSourceStep("tap "+tap.Name, "Remove source", func() error {
+				if !tapped[tap.Name] {
+					fmt.Fprintf(iostream.Out, "Tap %s is not present.\n", tap.Name)
+					return nil
+				}
+				fmt.Fprintf(iostream.Out, "Untapping %s...\n", tap.Name)
+				return executeCommand("brew untap "+shellQuote(tap.Name), ctx)
+			})
+			stats = append(stats, stat)
+			if err != nil {
+				return stats, sourceError(iostream, span, stat.Name, err)
+			}
+		}
+	}
+
+	aptRemoved := false
+	for _, src := range sources.Apt {
+		paths := []string{aptListPath(src), aptKeyringPath(src)}
+		stat, err := runSourceStep("apt "+src.Name, "Remove source", func() error {
+			removed, err := removeFiles(iostream, paths, ctx)
+			aptRemoved = aptRemoved || removed
+			return err
+		})
+		stats = append(stats, stat)
+		if err != nil {
+			return stats, sourceError(iostream, span, stat.Name, err)
+		}
+	}
+	if aptRemoved {
+		if err := executeCommand("sudo apt-get update", ctx); err != nil {
+			return stats, sourceError(iostream, span, "apt", err)
+		}
+	}
+
+	for _, src := range sources.Dnf {
+		paths := []string{dnfRepoPath(src)}
+		stat, err := runSourceStep("dnf "+src.Name, "Remove source", func() error {
+			_, err := removeFiles(iostream, paths, ctx)
+			return err
+		})
+		stats = append(stats, stat)
+		if err != nil {
+			return stats, sourceError(iostream, span, stat.Name, err)
+		}
+	}
+
+	span.SetTag("status", "success")
+	return stats, nil
+}
+
+func applyAptSources(iostream *iostreams.IOStreams, sources []utils.AptSource, ctx context.Context) ([]*utils.Stats, error) {
+	cs := iostream.ColorScheme()
+	var stats []*utils.Stats
+
+	if _, err := lookPath("apt-get"); err != nil {
+		fmt.Fprintln(iostream.Out, cs.Yellow("apt-get not found, skipping apt sources."))
+		for _, src := range sources {
+			stats = append(stats, &utils.Stats{Name: "apt " + src.Name, Operation: "Add source", Status: "skipped"})
+		}
+		return stats, nil
+	}
+
+	var codename string
+	changed := false
+	for _, src := range sources {
+		src := src
+		stat, err := runSourceStep("apt "+src.Name, "Add source", func() error {
+			suite := src.Suite
+			if suite == "" {
+				if codename == "" {
+					out, err := commandOutput(`. /etc/os-release && echo "$VERSION_CODENAME"`, ctx)
+					if err != nil || out == "" {
+						return fmt.Errorf("could not determine distribution codename, set suite explicitly: %v", err)
+					}
+					codename = out
+				}
+				suite = codename
+			}
+
+			if src.KeyURL != "" {
+				keyring := aptKeyringPath(src)
+				if _, err := os.Stat(keyring); os.IsNotExist(err) {
+					fmt.Fprintf(iostream.Out, "Adding signing key for %s...\n", src.Name)
+					command := fmt.Sprintf("sudo install -d -m 0755 %s && curl -fsSL %s | sudo gpg --dearmor --yes -o %s",
+						shellQuote(aptKeyringDir), shellQuote(src.KeyURL), shellQuote(keyring))
+					if err := executeCommand(command, ctx); err != nil {
+						return err
+					}
+					changed = true
+				}
+			}
+
+			written, err := writeRootFile(iostream, aptListPath(src), aptSourceLine(src, suite), ctx)
+			changed = changed || written
+			return err
+		})
+		stats = append(stats, stat)
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	if changed {
+		fmt.Fprintln(iostream.Out, "Refreshing apt package lists...")
+		if err := executeCommand("sudo apt-get update", ctx); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func applyDnfSources(iostream *iostreams.IOStreams, sources []utils.DnfSource, ctx context.Context) ([]*utils.Stats, error) {
+	cs := iostream.ColorScheme()
+	var stats []*utils.Stats
+
+	if _, err := lookPath("dnf"); err != nil {
+		fmt.Fprintln(iostream.Out, cs.Yellow("dnf not found, skipping dnf sources."))
+		for _, src := range sources {
+			stats = append(stats, &utils.Stats{Name: "dnf " + src.Name, Operation: "Add source", Status: "skipped"})
+		}
+		return stats, nil
+	}
+
+	for _, src := range sources {
+		src := src
+		stat, err := runSourceStep("dnf "+src.Name, "Add source", func() error {
+			written, err := writeRootFile(iostream, dnfRepoPath(src), dnfRepoFile(src), ctx)
+			if err != nil || !written || src.KeyURL == "" {
+				return err
+			}
+			fmt.Fprintf(iostream.Out, "Importing signing key for %s...\n", src.Name)
+			return executeCommand("sudo rpm --import "+shellQuote(src.KeyURL), ctx)
+		})
+		stats = append(stats, stat)
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// runSourceStep times step and records its outcome as a Stats row.
+func runSourceStep(name, operation string, step func() error) (*utils.Stats, error) {
+	startTime := time.Now()
+	stat := &utils.Stats{Name: name, Operation: operation}
+	err := step()
+	stat.Duration = time.Since(startTime)
+	if err != nil {
+		stat.Status = "error"
+	} else {
+		stat.Status = "success"
+	}
+	return stat, err
+}
+
+func sourceError(iostream *iostreams.IOStreams, span tracer.Span, name string, err error) error {
+	fmt.Fprintf(iostream.ErrOut, iostream.ColorScheme().Red("Failed to configure source %s: %v\n"), name, err)
+	span.SetTag("status", "failed")
+	span.SetTag("error", err)
+	return err
+}
+
+func installedTaps(ctx context.Context) (map[string]bool, error) {
+	out, err := commandOutput("brew tap", ctx)
+	if err != nil {
+		return nil, err
+	}
+	taps := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			taps[line] = true
+		}
+	}
+	return taps, nil
+}
+
+func tapCommand(tap utils.Tap) string {
+	if tap.URL != "" {
+		return fmt.Sprintf("brew tap %s %s", shellQuote(tap.Name), shellQuote(tap.URL))
+	}
+	return "brew tap " + shellQuote(tap.Name)
+}
+
+func aptKeyringPath(src utils.AptSource) string {
+	return filepath.Join(aptKeyringDir, src.Name+".gpg")
+}
+
+func aptListPath(src utils.AptSource) string {
+	return filepath.Join(aptSourcesDir, src.Name+".list")
+}
+
+func aptSourceLine(src utils.AptSource, suite string) string {
+	components := src.Components
+	if len(components) == 0 {
+		components = []string{"main"}
+	}
+	options := ""
+	if src.KeyURL != "" {
+		options = fmt.Sprintf("[signed-by=%s] ", aptKeyringPath(src))
+	}
+	return fmt.Sprintf("deb %s%s %s %s\n", options, src.URI, suite, strings.Join(components, " "))
+}
+
+func dnfRepoPath(src utils.DnfSource) string {
+	return filepath.Join(dnfReposDir, src.Name+".repo")
+}
+
+func dnfRepoFile(src utils.DnfSource) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]\nname=%s\nbaseurl=%s\nenabled=1\n", src.Name, src.Name, src.BaseURL)
+	if src.KeyURL != "" {
+		fmt.Fprintf(&b, "gpgcheck=1\ngpgkey=%s\n", src.KeyURL)
+	} else {
+		b.WriteString("gpgcheck=0\n")
+	}
+	return b.String()
+}
+
+// writeRootFile writes content to a root-owned path through sudo, unless the
+// file already has exactly that content. It reports whether it wrote anything.
+func writeRootFile(iostream *iostreams.IOStreams, path, content string, ctx context.Context) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && string(existing) == content {
+		fmt.Fprintf(iostream.Out, "%s is up to date.\n", path)
+		return false, nil
+	}
+	fmt.Fprintf(iostream.Out, "Writing %s...\n", path)
+	command := fmt.Sprintf("printf '%%s' %s | sudo tee %s > /dev/null", shellQuote(content), shellQuote(path))
+	if err := executeCommand(command, ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// removeFiles deletes the given root-owned files and reports whether any of them existed.
+func removeFiles(iostream *iostreams.IOStreams, paths []string, ctx context.Context) (bool, error) {
+	var existing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, shellQuote(path))
+		}
+	}
+	if len(existing) == 0 {
+		fmt.Fprintln(iostream.Out, "Nothing to remove.")
+		return false, nil
+	}
+	fmt.Fprintf(iostream.Out, "Removing %s...\n", strings.Join(existing, " "))
+	return true, executeCommand("sudo rm -f "+strings.Join(existing, " "), ctx)
+}
+
+func commandOutput(command string, ctx context.Context) (string, error) {
+	cmd := execCommandContext(ctx, "sh", "-c", command)
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,161 @@
+package homebrew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordCommands replaces execCommandContext with a fake that records every
+// command and answers `brew tap` with the given taps.
+func recordCommands(t *testing.T, taps ...string) *[]string {
+	executed := []string{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		command := strings.Join(args[1:], " ")
+		executed = append(executed, command)
+		switch {
+		case command == "brew tap":
+			return exec.Command("echo", strings.Join(taps, "\n"))
+		case strings.Contains(command, "VERSION_CODENAME"):
+			return exec.Command("echo", "jammy")
+		}
+		return exec.Command("true")
+	}
+	t.Cleanup(func() { execCommandContext = oldExecCommandContext })
+	return &executed
+}
+
+func withSourceDirs(t *testing.T, available ...string) string {
+	dir := t.TempDir()
+	oldApt, oldKeys, oldDnf, oldLookPath := aptSourcesDir, aptKeyringDir, dnfReposDir, lookPath
+	aptSourcesDir = filepath.Join(dir, "sources.list.d")
+	aptKeyringDir = filepath.Join(dir, "keyrings")
+	dnfReposDir = filepath.Join(dir, "yum.repos.d")
+	lookPath = func(file string) (string, error) {
+		for _, name := range available {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() {
+		aptSourcesDir, aptKeyringDir, dnfReposDir, lookPath = oldApt, oldKeys, oldDnf, oldLookPath
+	})
+	return dir
+}
+
+func TestApplySourcesTaps(t *testing.T) {
+	withSourceDirs(t)
+	executed := recordCommands(t, "homebrew/core", "hashicorp/tap")
+	ios, _, out, _ := iostreams.Test()
+
+	sources := utils.Sources{Taps: []utils.Tap{
+		{Name: "hashicorp/tap"},
+		{Name: "acme/tools", URL: "https://example.com/acme/tools.git"},
+	}}
+	stats, err := ApplySources(ios, sources, context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"brew tap", "brew tap 'acme/tools' 'https://example.com/acme/tools.git'"}, *executed)
+	assert.Contains(t, out.String(), "Tap hashicorp/tap is already present.")
+	require.Len(t, stats, 2)
+	assert.Equal(t, "tap hashicorp/tap", stats[0].Name)
+	assert.Equal(t, "Add source", stats[0].Operation)
+	assert.Equal(t, "success", stats[1].Status)
+}
+
+func TestApplySourcesApt(t *testing.T) {
+	withSourceDirs(t, "apt-get")
+	sources := utils.Sources{Apt: []utils.AptSource{
+		{Name: "hashicorp", URI: "https://apt.releases.hashicorp.com", KeyURL: "https://apt.releases.hashicorp.com/gpg"},
+	}}
+
+	t.Run("missing source is written", func(t *testing.T) {
+		executed := recordCommands(t)
+		ios, _, _, _ := iostreams.Test()
+
+		stats, err := ApplySources(ios, sources, context.Background())
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		assert.Equal(t, "success", stats[0].Status)
+
+		line := fmt.Sprintf("deb [signed-by=%s] https://apt.releases.hashicorp.com jammy main\n", filepath.Join(aptKeyringDir, "hashicorp.gpg"))
+		joined := strings.Join(*executed, "\n")
+		assert.Contains(t, joined, "curl -fsSL 'https://apt.releases.hashicorp.com/gpg' | sudo gpg --dearmor")
+		assert.Contains(t, joined, "sudo tee '"+filepath.Join(aptSourcesDir, "hashicorp.list")+"'")
+		assert.Contains(t, joined, line)
+		assert.Equal(t, "sudo apt-get update", (*executed)[len(*executed)-1])
+	})
+
+	t.Run("existing source is left alone", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(aptSourcesDir, 0755))
+		require.NoError(t, os.MkdirAll(aptKeyringDir, 0755))
+		src := sources.Apt[0]
+		require.NoError(t, os.WriteFile(aptKeyringPath(src), []byte("key"), 0644))
+		require.NoError(t, os.WriteFile(aptListPath(src), []byte(aptSourceLine(src, "jammy")), 0644))
+
+		executed := recordCommands(t)
+		ios, _, out, _ := iostreams.Test()
+
+		_, err := ApplySources(ios, sources, context.Background())
+		require.NoError(t, err)
+		assert.NotContains(t, strings.Join(*executed, "\n"), "sudo")
+		assert.Contains(t, out.String(), "is up to date")
+	})
+}
+
+func TestApplySourcesSkipsMissingPackageManager(t *testing.T) {
+	withSourceDirs(t)
+	executed := recordCommands(t)
+	ios, _, out, _ := iostreams.Test()
+
+	sources := utils.Sources{Dnf: []utils.DnfSource{{Name: "hashicorp", BaseURL: "https://rpm.releases.hashicorp.com"}}}
+	stats, err := ApplySources(ios, sources, context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, *executed)
+	assert.Contains(t, out.String(), "dnf not found")
+	require.Len(t, stats, 1)
+	assert.Equal(t, "skipped", stats[0].Status)
+}
+
+func TestRemoveSources(t *testing.T) {
+	withSourceDirs(t, "apt-get")
+	executed := recordCommands(t, "hashicorp/tap")
+	ios, _, _, _ := iostreams.Test()
+
+	src := utils.AptSource{Name: "hashicorp", URI: "https://apt.releases.hashicorp.com"}
+	require.NoError(t, os.MkdirAll(aptSourcesDir, 0755))
+	require.NoError(t, os.WriteFile(aptListPath(src), []byte("deb"), 0644))
+
+	sources := utils.Sources{
+		Taps: []utils.Tap{{Name: "hashicorp/tap"}, {Name: "not/tapped"}},
+		Apt:  []utils.AptSource{src},
+	}
+	stats, err := RemoveSources(ios, sources, context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"brew tap",
+		"brew untap 'hashicorp/tap'",
+		"sudo rm -f '" + aptListPath(src) + "'",
+		"sudo apt-get update",
+	}, *executed)
+	require.Len(t, stats, 3)
+	for _, stat := range stats {
+		assert.Equal(t, "Remove source", stat.Operation)
+		assert.Equal(t, "success", stat.Status)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/XiaoConstantine/mycli/pkg/iostreams"
@@ -28,6 +29,7 @@ import (
 //	-c, --config string   Path to the configuration file (default "~/.mycli/config.yaml")
 //	-f, --force           Force reinstall of tools even if they are already installed
 //	--non-interactive     Run in non-interactive mode
+//	--offline             Use only cached install scripts
 //
 // The function sets up the command's flags and its Run function. It uses the provided IOStreams
 // for input/output operations and a StatsCollector for gathering installation statistics.
@@ -49,6 +51,7 @@ func NewInstallToolsCmd(iostream *iostreams.IOStreams, statsCollector *utils.Sta
 	var configFile string
 	var force bool
 	var nonInteractive bool
+	var offline bool
 	var toolStats []*utils.Stats
 
 	cmd := &cobra.Command{
@@ -61,6 +64,12 @@ func NewInstallToolsCmd(iostream *iostreams.IOStreams, statsCollector *utils.Sta
 		RunE: func(cmd *cobra.Command, args []string) error {
 			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "install_tools")
 			defer span.Finish()
+			UseRememberedPrefix()
+			if offline {
+				ctx = utils.WithOffline(ctx, true)
+				// brew would otherwise try to update itself before every install.
+				os.Setenv("HOMEBREW_NO_AUTO_UPDATE", "1")
+			}
 
 			config, err := utils.LoadToolsConfig(configFile)
 			if err != nil {
@@ -77,14 +86,16 @@ func NewInstallToolsCmd(iostream *iostreams.IOStreams, statsCollector *utils.Sta
 	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Force reinstall of casks")
 	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Run in non-interactive mode")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Use only cached install scripts, never download them")
 	return cmd
 }
 
 // InstallToolsFromConfig installs tools based on the provided configuration.
 //
 // This function is responsible for the actual installation process of the tools.
-// It reads the tool definitions from the config, checks if they need to be installed,
-// and executes the installation commands.
+// It first applies the package sources declared in the config, then reads the tool
+// definitions, checks if they need to be installed, and executes the installation commands.
+// Consecutive Homebrew tools with the same method are installed with one brew invocation.
 //
 // Parameters:
 //   - iostream: An iostreams.IOStreams instance for I/O operations.
@@ -103,86 +114,237 @@ func InstallToolsFromConfig(iostream *iostreams.IOStreams, config *utils.ToolCon
 
 	parentSpan, ctx := tracer.StartSpanFromContext(ctx, "install_tools")
 	defer parentSpan.Finish()
-	// Log tool installation details
-	for _, tool := range config.Tools {
-		toolSpan, toolCtx := tracer.StartSpanFromContext(ctx, fmt.Sprintf("install_%s", tool.Name))
-		toolStartTime := time.Now()
-		toolStat := utils.Stats{
-			Name:      tool.Name,
-			Operation: "Install",
-		}
+	ctx = utils.WithAuth(ctx, config.Auth)
 
-		fmt.Fprintf(iostream.Out, cs.Green("Installing tool %s...\n"), tool)
-		if tool.InstallCommand != "" {
-			fmt.Fprintf(iostream.Out, "Installing %s using custom command %s...\n", tool.Name, tool.InstallCommand)
-			if err := executeCommand(tool.InstallCommand, toolCtx); err != nil {
-				fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to install %s: %v\n"), tool.Name, err)
-				toolStat.Status = "error"
-				toolStat.Duration = time.Since(toolStartTime)
-				stats = append(stats, &toolStat)
-				toolSpan.SetTag("status", "failed")
-				toolSpan.SetTag("error", err)
-				return stats, err
-			}
-			// Run post-install commands if they exist
-			if tool.PostInstall != nil && len(tool.PostInstall) > 0 {
-				for _, cmd := range tool.PostInstall {
-					expandedCmd := os.ExpandEnv(cmd) // Expand environment variables in the command
-					if err := executeCommand(expandedCmd, ctx); err != nil {
-						fmt.Fprintf(iostream.ErrOut, "Failed to run post-install command for %s: %v\n", tool.Name, err)
-						// Decide whether to continue or return based on the error
-					}
-				}
-			}
-			toolDuration := time.Since(toolStartTime)
-			toolStat.Status = "success"
-			toolStat.Duration = toolDuration
-			stats = append(stats, &toolStat)
-		} else {
-			// Default to Homebrew installation
-			command := "brew install"
-			if tool.Method == "cask" {
-				command += " --cask"
-			}
-			if force {
-				command += " --force"
-			}
-			fmt.Fprintf(iostream.Out, "Installing %s using Homebrew with %s...\n", tool.Name, command)
-			if err := executeCommand(fmt.Sprintf("%s %s", command, tool.Name), toolCtx); err != nil {
-				toolStat.Status = "error"
-				toolStat.Duration = time.Since(toolStartTime)
-				stats = append(stats, &toolStat)
-
-				toolSpan.SetTag("status", "failed")
-				toolSpan.SetTag("error", err)
-				fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to install %s: %v\n"), tool.Name, err)
+	// Taps and repositories have to be in place before anything that depends on them.
+	sourceStats, err := ApplySources(iostream, config.Sources, ctx)
+	stats = append(stats, sourceStats...)
+	if err != nil {
+		return stats, err
+	}
+
+	for i := 0; i < len(config.Tools); {
+		tool := config.Tools[i]
+		if !isBrew(tool) {
+			toolStat, err := installCustomTool(iostream, tool, ctx)
+			stats = append(stats, toolStat)
+			if err != nil {
 				return stats, err
 			}
+			i++
+			continue
+		}
 
-			// Run post-install commands
-			// Run post-install commands if they exist
-			if tool.PostInstall != nil && len(tool.PostInstall) > 0 {
-				for _, cmd := range tool.PostInstall {
-					expandedCmd := os.ExpandEnv(cmd) // Expand environment variables in the command
-					if err := executeCommand(expandedCmd, ctx); err != nil {
-						fmt.Fprintf(iostream.ErrOut, "Failed to run post-install command for %s: %v\n", tool.Name, err)
-						// Decide whether to continue or return based on the error
-					}
-				}
-			}
-			toolDuration := time.Since(toolStartTime)
-			toolStat.Status = "success"
-			toolStat.Duration = toolDuration
-			stats = append(stats, &toolStat)
+		// Group consecutive brew-backed tools that share an install method, so brew
+		// checks for updates and resolves the dependency graph once per batch.
+		j := i + 1
+		for j < len(config.Tools) && isBrew(config.Tools[j]) && isCask(config.Tools[j]) == isCask(tool) {
+			j++
+		}
+		batchStats, err := runBrewBatch(iostream, "install", config.Tools[i:j], ctx, force)
+		stats = append(stats, batchStats...)
+		if err != nil {
+			return stats, err
 		}
-		toolSpan.SetTag("status", "success")
-		toolSpan.Finish()
+		i = j
 	}
 
 	fmt.Fprintln(iostream.Out, cs.GreenBold("All requested tools and casks have been installed successfully."))
 	return stats, nil
 }
 
+func installCustomTool(iostream *iostreams.IOStreams, tool utils.Tool, ctx context.Context) (*utils.Stats, error) {
+	cs := iostream.ColorScheme()
+	toolSpan, toolCtx := tracer.StartSpanFromContext(ctx, fmt.Sprintf("install_%s", tool.Name))
+	defer toolSpan.Finish()
+	toolStartTime := time.Now()
+	toolStat := &utils.Stats{
+		Name:      tool.Name,
+		Operation: "Install",
+	}
+
+	fmt.Fprintf(iostream.Out, cs.Green("Installing tool %s...\n"), tool)
+	var err error
+	if tool.ScriptURL != "" {
+		fmt.Fprintf(iostream.Out, "Installing %s using script %s...\n", tool.Name, utils.RedactURL(tool.ScriptURL))
+		err = runInstallScript(tool, toolCtx)
+	} else if isRelease(tool) {
+		fmt.Fprintf(iostream.Out, "Installing %s from the GitHub releases of %s...\n", tool.Name, tool.Repo)
+		err = installRelease(iostream, tool, tool.Version, toolCtx)
+	} else if backend, ok := languageBackendFor(tool); ok {
+		command := backend.install(tool.Name, tool.Version)
+		fmt.Fprintf(iostream.Out, "Installing %s using %s with %s...\n", tool.Name, tool.Method, command)
+		err = executeCommand(command, toolCtx)
+	} else {
+		fmt.Fprintf(iostream.Out, "Installing %s using custom command %s...\n", tool.Name, tool.InstallCommand)
+		err = executeCommand(tool.InstallCommand, toolCtx)
+	}
+	if err != nil {
+		fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to install %s: %v\n"), tool.Name, err)
+		toolStat.Status = "error"
+		toolStat.Duration = time.Since(toolStartTime)
+		toolSpan.SetTag("status", "failed")
+		toolSpan.SetTag("error", err)
+		return toolStat, err
+	}
+	runPostInstall(iostream, tool, ctx)
+
+	toolStat.Status = "success"
+	toolStat.Duration = time.Since(toolStartTime)
+	toolSpan.SetTag("status", "success")
+	return toolStat, nil
+}
+
+// runBrewBatch runs `brew <subcommand>` (install or upgrade) for tools, which
+// must all share the same Homebrew method, with a single brew invocation.
+//
+// When a batch of several tools fails, brew does not tell us which formula was
+// at fault, so the tools are retried one at a time to attribute the failure.
+func runBrewBatch(iostream *iostreams.IOStreams, subcommand string, tools []utils.Tool, ctx context.Context, force bool) ([]*utils.Stats, error) {
+	cs := iostream.ColorScheme()
+	var stats []*utils.Stats
+
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	operation := strings.ToUpper(subcommand[:1]) + subcommand[1:]
+	progress := map[string]string{"install": "Installing", "upgrade": "Upgrading"}[subcommand]
+
+	batchSpan, batchCtx := tracer.StartSpanFromContext(ctx, fmt.Sprintf("%s_%s", subcommand, strings.Join(names, "+")))
+	defer batchSpan.Finish()
+	batchStartTime := time.Now()
+
+	command := "brew " + subcommand
+	if isCask(tools[0]) {
+		command += " --cask"
+	}
+	if force {
+		command += " --force"
+	}
+	fmt.Fprintf(iostream.Out, "%s %s using Homebrew with %s...\n", progress, strings.Join(names, ", "), command)
+	err := executeCommand(fmt.Sprintf("%s %s", command, strings.Join(names, " ")), batchCtx)
+
+	if err != nil && len(tools) > 1 {
+		fmt.Fprintf(iostream.ErrOut, cs.Yellow("Batch %s of %s failed, retrying one at a time...\n"), subcommand, strings.Join(names, ", "))
+		batchSpan.SetTag("status", "retried")
+		for _, tool := range tools {
+			toolStats, err := runBrewBatch(iostream, subcommand, []utils.Tool{tool}, ctx, force)
+			stats = append(stats, toolStats...)
+			if err != nil {
+				return stats, err
+			}
+		}
+		return stats, nil
+	}
+
+	// Every tool in the batch gets an equal share of the brew run, so the
+	// total in the stats table still adds up to the wall-clock time.
+	share := time.Since(batchStartTime) / time.Duration(len(tools))
+	if err != nil {
+		fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to %s %s: %v\n"), subcommand, tools[0].Name, err)
+		batchSpan.SetTag("status", "failed")
+		batchSpan.SetTag("error", err)
+		stats = append(stats, &utils.Stats{Name: tools[0].Name, Operation: operation, Status: "error", Duration: share})
+		return stats, err
+	}
+
+	for _, tool := range tools {
+		postStartTime := time.Now()
+		if subcommand == "install" {
+			runPostInstall(iostream, tool, ctx)
+		}
+		stats = append(stats, &utils.Stats{
+			Name:      tool.Name,
+			Operation: operation,
+			Status:    "success",
+			Duration:  share + time.Since(postStartTime),
+		})
+	}
+	batchSpan.SetTag("status", "success")
+	return stats, nil
+}
+
+func runPostInstall(iostream *iostreams.IOStreams, tool utils.Tool, ctx context.Context) {
+	for _, cmd := range tool.PostInstall {
+		expandedCmd := os.ExpandEnv(cmd) // Expand environment variables in the command
+		if err := executeCommand(expandedCmd, ctx); err != nil {
+			fmt.Fprintf(iostream.ErrOut, "Failed to run post-install command for %s: %v\n", tool.Name, err)
+			// Decide whether to continue or return based on the error
+		}
+	}
+	if err := applyShellSnippets(iostream, tool); err != nil {
+		fmt.Fprintf(iostream.ErrOut, "Failed to update shell snippets for %s: %v\n", tool.Name, err)
+	}
+}
+
+// applyShellSnippets keeps the tool's paths, env and shell_snippets in a
+// managed block of the user's shell rc file, named after the tool. A tool
+// without any has the block left over from an earlier config removed.
+func applyShellSnippets(iostream *iostreams.IOStreams, tool utils.Tool) error {
+	rc, err := utils.DefaultShellRC()
+	if err != nil {
+		return err
+	}
+	var changed bool
+	if lines := utils.DetectShell().ShellLines(tool); len(lines) == 0 {
+		changed, err = utils.RemoveManagedBlock(rc, tool.Name)
+	} else {
+		changed, err = utils.SetManagedBlock(rc, tool.Name, strings.Join(lines, "\n"))
+	}
+	if err != nil {
+		return err
+	}
+	if changed {
+		fmt.Fprintf(iostream.Out, "Updated shell snippets for %s in %s\n", tool.Name, rc)
+	}
+	return nil
+}
+
+func isCask(tool utils.Tool) bool {
+	return tool.Method == "cask"
+}
+
+// isBrew reports whether tool is installed with Homebrew, as a formula or a
+// cask.
+func isBrew(tool utils.Tool) bool {
+	_, language := languageBackendFor(tool)
+	return !isCustom(tool) && !language && !isRelease(tool)
+}
+
+// isCustom reports whether tool is installed by its own command or script
+// rather than by Homebrew.
+func isCustom(tool utils.Tool) bool {
+	return tool.InstallCommand != "" || tool.ScriptURL != ""
+}
+
+// runInstallScript downloads the tool's install script, checks it against
+// script_sha256 when set and runs it with sh. A script that doesn't match is
+// never run.
+func runInstallScript(tool utils.Tool, ctx context.Context) error {
+	script, err := utils.Download(ctx, tool.ScriptURL)
+	if err != nil {
+		return err
+	}
+	if err := utils.VerifySHA256(tool.ScriptURL, script, tool.ScriptSHA256); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "mycli-install-*.sh")
+	if err != nil {
+		return fmt.Errorf("failed to create install script: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(script); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write install script: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write install script: %v", err)
+	}
+	return executeCommand("sh "+shellQuote(f.Name()), ctx)
+}
+
 func executeCommand(command string, ctx context.Context) error {
 	cmd := execCommandContext(ctx, "sh", "-c", command)
 	cmd.Stdout = os.Stdout
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -14,6 +17,7 @@ import (
 	"github.com/XiaoConstantine/mycli/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock for execCommandContext.
@@ -256,3 +260,133 @@ tools:
 		assert.Equal(t, "success", stats[1].Status)
 	}
 }
+
+func TestInstallToolsFromConfigBatchesBrewInstalls(t *testing.T) {
+	ios, _, out, _ := iostreams.Test()
+	mockCmd := &mockCommandContext{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = mockCmd.CommandContext
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew install tool1 tool2"}).
+		Return(exec.Command("true"))
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "custom install command"}).
+		Return(exec.Command("true"))
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew install --cask cask1 cask2"}).
+		Return(exec.Command("true"))
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew install tool4"}).
+		Return(exec.Command("true"))
+
+	config := &utils.ToolConfig{
+		Tools: []utils.Tool{
+			{Name: "tool1"},
+			{Name: "tool2", Method: "brew"},
+			{Name: "tool3", InstallCommand: "custom install command"},
+			{Name: "cask1", Method: "cask"},
+			{Name: "cask2", Method: "cask"},
+			{Name: "tool4"},
+		},
+	}
+	stats, err := InstallToolsFromConfig(ios, config, context.Background(), false)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Installing tool1, tool2 using Homebrew with brew install...")
+	assert.Contains(t, out.String(), "Installing cask1, cask2 using Homebrew with brew install --cask...")
+
+	names := []string{}
+	for _, stat := range stats {
+		names = append(names, stat.Name)
+		assert.Equal(t, "success", stat.Status)
+	}
+	assert.Equal(t, []string{"tool1", "tool2", "tool3", "cask1", "cask2", "tool4"}, names)
+	mockCmd.AssertExpectations(t)
+}
+
+func TestInstallToolsFromConfigAttributesBatchFailure(t *testing.T) {
+	ios, _, _, errOut := iostreams.Test()
+	mockCmd := &mockCommandContext{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = mockCmd.CommandContext
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew install good bad later"}).
+		Return(exec.Command("false"))
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew install good"}).
+		Return(exec.Command("true"))
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew install bad"}).
+		Return(exec.Command("false"))
+
+	config := &utils.ToolConfig{
+		Tools: []utils.Tool{{Name: "good"}, {Name: "bad"}, {Name: "later"}},
+	}
+	stats, err := InstallToolsFromConfig(ios, config, context.Background(), false)
+	assert.Error(t, err)
+	assert.Contains(t, errOut.String(), "retrying one at a time")
+	assert.Contains(t, errOut.String(), "Failed to install bad")
+
+	if assert.Len(t, stats, 2) {
+		assert.Equal(t, "good", stats[0].Name)
+		assert.Equal(t, "success", stats[0].Status)
+		assert.Equal(t, "bad", stats[1].Name)
+		assert.Equal(t, "error", stats[1].Status)
+	}
+	mockCmd.AssertExpectations(t)
+}
+
+func TestInstallToolsFromConfigScriptURL(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	script := []byte("echo installing\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(script)
+	}))
+	defer server.Close()
+
+	ios, _, out, _ := iostreams.Test()
+	mockCmd := &mockCommandContext{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = mockCmd.CommandContext
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	runsScript := mock.MatchedBy(func(args []string) bool {
+		return len(args) == 2 && args[0] == "-c" && strings.HasPrefix(args[1], "sh '") && strings.Contains(args[1], "mycli-install-")
+	})
+	mockCmd.On("CommandContext", mock.Anything, "sh", runsScript).Return(exec.Command("true")).Once()
+
+	config := &utils.ToolConfig{
+		Tools: []utils.Tool{{Name: "uv", ScriptURL: server.URL + "/install.sh", ScriptSHA256: utils.SHA256Hex(script)}},
+	}
+	stats, err := InstallToolsFromConfig(ios, config, context.Background(), false)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Installing uv using script "+server.URL+"/install.sh...")
+	assert.Equal(t, "success", stats[0].Status)
+
+	// A script that doesn't match its pin is never run.
+	config.Tools[0].ScriptSHA256 = utils.SHA256Hex([]byte("something else"))
+	stats, err = InstallToolsFromConfig(ios, config, context.Background(), false)
+	assert.ErrorContains(t, err, "checksum mismatch")
+	assert.Equal(t, "error", stats[0].Status)
+	mockCmd.AssertExpectations(t)
+}
+
+func TestApplyShellSnippets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/zsh")
+	rc := filepath.Join(home, ".zshrc")
+	ios, _, out, _ := iostreams.Test()
+
+	tool := utils.Tool{Name: "zoxide", ShellSnippets: []string{`eval "$(zoxide init zsh)"`, "alias cd=z"}}
+	require.NoError(t, applyShellSnippets(ios, tool))
+	require.NoError(t, applyShellSnippets(ios, tool))
+	assert.Equal(t, 1, strings.Count(out.String(), "Updated shell snippets for zoxide"))
+
+	content, err := os.ReadFile(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "# >>> mycli: zoxide >>>\neval \"$(zoxide init zsh)\"\nalias cd=z\n# <<< mycli: zoxide <<<\n", string(content))
+
+	// Dropping the snippets from the config removes the block again.
+	tool.ShellSnippets = nil
+	require.NoError(t, applyShellSnippets(ios, tool))
+	content, err = os.ReadFile(rc)
+	require.NoError(t, err)
+	assert.Empty(t, string(content))
+}
@@ -0,0 +1,259 @@
+package homebrew
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Version status values reported by ToolVersions.
+const (
+	StatusUpToDate     = "up to date"
+	StatusOutdated     = "outdated"
+	StatusPinned       = "pinned"
+	StatusNotInstalled = "not installed"
+	StatusUnknown      = "unknown"
+)
+
+// ToolVersion describes the installed and latest available version of a configured tool.
+type ToolVersion struct {
+	Name      string `json:"name"`
+	Backend   string `json:"backend"`
+	Installed string `json:"installed"`
+	Latest    string `json:"latest"`
+	Pinned    bool   `json:"pinned"`
+	Status    string `json:"status"`
+}
+
+// brewInfo is the subset of `brew info --json=v2` that we care about.
+type brewInfo struct {
+	Formulae []struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+		Versions struct {
+			Stable string `json:"stable"`
+		} `json:"versions"`
+		Revision  int  `json:"revision"`
+		Pinned    bool `json:"pinned"`
+		Installed []struct {
+			Version string `json:"version"`
+		} `json:"installed"`
+	} `json:"formulae"`
+	Casks []struct {
+		Token     string `json:"token"`
+		FullToken string `json:"full_token"`
+		Version   string `json:"version"`
+		Installed string `json:"installed"`
+	} `json:"casks"`
+}
+
+// ToolVersions looks up the installed and latest versions of tools.
+//
+// Homebrew formulae and casks are queried with one `brew info` call each, npm,
+// pipx and cargo tools with their package manager, and github-release tools
+// with the GitHub releases API. Tools installed with a custom command or script
+// can't be inspected and are reported as unknown, as are tools whose package
+// manager fails to answer. A tool with a version pin
+// in the config is reported as pinned, as is a formula pinned with `brew pin`.
+func ToolVersions(ctx context.Context, tools []utils.Tool) ([]ToolVersion, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "tool_versions")
+	defer span.Finish()
+
+	var formulae, casks []string
+	for _, tool := range tools {
+		if !isBrew(tool) {
+			continue
+		}
+		if isCask(tool) {
+			casks = append(casks, tool.Name)
+		} else {
+			formulae = append(formulae, tool.Name)
+		}
+	}
+
+	found := make(map[string]ToolVersion)
+	for _, query := range []struct {
+		names []string
+		flag  string
+	}{{formulae, ""}, {casks, " --cask"}} {
+		if len(query.names) == 0 {
+			continue
+		}
+		info, err := queryBrewInfo(ctx, query.flag, query.names)
+		if err != nil {
+			span.SetTag("error", err)
+			return nil, err
+		}
+		for _, f := range info.Formulae {
+			v := ToolVersion{Backend: "brew", Latest: f.Versions.Stable, Pinned: f.Pinned}
+			if f.Revision > 0 {
+				v.Latest = fmt.Sprintf("%s_%d", v.Latest, f.Revision)
+			}
+			if len(f.Installed) > 0 {
+				v.Installed = f.Installed[len(f.Installed)-1].Version
+			}
+			found[f.Name], found[f.FullName] = v, v
+		}
+		for _, c := range info.Casks {
+			v := ToolVersion{Backend: "cask", Latest: c.Version, Installed: c.Installed}
+			found[c.Token], found[c.FullToken] = v, v
+		}
+	}
+
+	versions := make([]ToolVersion, 0, len(tools))
+	for _, tool := range tools {
+		v, ok := found[tool.Name]
+		if backend, language := languageBackendFor(tool); language {
+			v, ok = languageVersion(ctx, tool, backend), true
+		} else if isRelease(tool) {
+			v, ok = releaseVersion(ctx, tool), true
+		}
+		switch {
+		case isCustom(tool):
+			v = ToolVersion{Backend: "custom"}
+		case !ok:
+			v = ToolVersion{Backend: "brew"}
+			if isCask(tool) {
+				v.Backend = "cask"
+			}
+		}
+		v.Name = tool.Name
+		v.Pinned = v.Pinned || tool.Version != ""
+		if v.Status == "" {
+			v.Status = versionStatus(v, ok && !isCustom(tool))
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// languageVersion asks tool's package manager for its installed and latest
+// versions. When it can't tell, the status is unknown rather than failing
+// the whole lookup.
+func languageVersion(ctx context.Context, tool utils.Tool, backend languageBackend) ToolVersion {
+	span, ctx := tracer.StartSpanFromContext(ctx, "tool_version_"+tool.Method)
+	defer span.Finish()
+
+	v := ToolVersion{Backend: tool.Method}
+	var err error
+	if v.Installed, err = backend.installed(ctx, tool.Name); err == nil && v.Installed != "" {
+		v.Latest, err = backend.latest(ctx, tool.Name)
+	}
+	if err != nil {
+		span.SetTag("error", err)
+		v.Status = StatusUnknown
+	}
+	return v
+}
+
+func versionStatus(v ToolVersion, known bool) string {
+	switch {
+	case !known:
+		return StatusUnknown
+	case v.Installed == "":
+		return StatusNotInstalled
+	case v.Pinned:
+		return StatusPinned
+	case v.Installed != v.Latest:
+		return StatusOutdated
+	}
+	return StatusUpToDate
+}
+
+// queryBrewInfo runs `brew info --json=v2` for names. If brew rejects the whole
+// batch (for example because one name is unknown) the names are queried one by
+// one and the ones brew doesn't know are left out of the result.
+func queryBrewInfo(ctx context.Context, flag string, names []string) (*brewInfo, error) {
+	out, err := commandOutput(fmt.Sprintf("brew info --json=v2%s %s", flag, strings.Join(names, " ")), ctx)
+	if err == nil {
+		var info brewInfo
+		if err := json.Unmarshal([]byte(out), &info); err != nil {
+			return nil, fmt.Errorf("failed to parse brew info output: %v", err)
+		}
+		return &info, nil
+	}
+	if len(names) == 1 {
+		return &brewInfo{}, nil
+	}
+
+	merged := &brewInfo{}
+	for _, name := range names {
+		info, err := queryBrewInfo(ctx, flag, []string{name})
+		if err != nil {
+			return nil, err
+		}
+		merged.Formulae = append(merged.Formulae, info.Formulae...)
+		merged.Casks = append(merged.Casks, info.Casks...)
+	}
+	return merged, nil
+}
+
+// UpgradeTools upgrades the given tools with Homebrew, their package manager
+// or the latest GitHub release, batching consecutive Homebrew tools that share an install method
+// just like InstallToolsFromConfig does. Callers are expected to have filtered
+// out pinned and custom tools already.
+func UpgradeTools(iostream *iostreams.IOStreams, tools []utils.Tool, ctx context.Context) ([]*utils.Stats, error) {
+	var stats []*utils.Stats
+	span, ctx := tracer.StartSpanFromContext(ctx, "upgrade_tools")
+	defer span.Finish()
+
+	for i := 0; i < len(tools); {
+		if backend, ok := languageBackendFor(tools[i]); ok || isRelease(tools[i]) {
+			var stat *utils.Stats
+			var err error
+			if ok {
+				stat, err = upgradeLanguageTool(iostream, tools[i], backend, ctx)
+			} else {
+				stat, err = upgradeReleaseTool(iostream, tools[i], ctx)
+			}
+			stats = append(stats, stat)
+			if err != nil {
+				span.SetTag("error", err)
+				return stats, err
+			}
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(tools) && isBrew(tools[j]) && isCask(tools[j]) == isCask(tools[i]) {
+			j++
+		}
+		batchStats, err := runBrewBatch(iostream, "upgrade", tools[i:j], ctx, false)
+		stats = append(stats, batchStats...)
+		if err != nil {
+			span.SetTag("error", err)
+			return stats, err
+		}
+		i = j
+	}
+	return stats, nil
+}
+
+func upgradeLanguageTool(iostream *iostreams.IOStreams, tool utils.Tool, backend languageBackend, ctx context.Context) (*utils.Stats, error) {
+	cs := iostream.ColorScheme()
+	span, ctx := tracer.StartSpanFromContext(ctx, fmt.Sprintf("upgrade_%s", tool.Name))
+	defer span.Finish()
+	startTime := time.Now()
+	stat := &utils.Stats{Name: tool.Name, Operation: "Upgrade", Status: "success"}
+
+	command := backend.upgrade(tool.Name)
+	fmt.Fprintf(iostream.Out, "Upgrading %s using %s with %s...\n", tool.Name, tool.Method, command)
+	err := executeCommand(command, ctx)
+	stat.Duration = time.Since(startTime)
+	if err != nil {
+		fmt.Fprintf(iostream.ErrOut, cs.Red("Failed to upgrade %s: %v\n"), tool.Name, err)
+		stat.Status = "error"
+		span.SetTag("status", "failed")
+		span.SetTag("error", err)
+		return stat, err
+	}
+	span.SetTag("status", "success")
+	return stat, nil
+}
@@ -0,0 +1,112 @@
+package homebrew
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const formulaeInfo = `{"formulae":[
+  {"name":"jq","full_name":"jq","versions":{"stable":"1.7.1"},"revision":0,"pinned":false,"installed":[{"version":"1.6"}]},
+  {"name":"git","full_name":"git","versions":{"stable":"2.46.0"},"revision":1,"pinned":false,"installed":[{"version":"2.46.0_1"}]},
+  {"name":"terraform","full_name":"hashicorp/tap/terraform","versions":{"stable":"1.9.0"},"pinned":true,"installed":[{"version":"1.8.0"}]},
+  {"name":"ripgrep","full_name":"ripgrep","versions":{"stable":"14.1.0"},"installed":[]}
+],"casks":[]}`
+
+const casksInfo = `{"formulae":[],"casks":[
+  {"token":"alacritty","full_token":"alacritty","version":"0.13.2","installed":"0.13.1"}
+]}`
+
+func TestToolVersions(t *testing.T) {
+	mockCmd := &mockCommandContext{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = mockCmd.CommandContext
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew info --json=v2 jq git hashicorp/tap/terraform ripgrep node"}).
+		Return(exec.Command("echo", formulaeInfo))
+	mockCmd.On("CommandContext", mock.Anything, "sh", []string{"-c", "brew info --json=v2 --cask alacritty"}).
+		Return(exec.Command("echo", casksInfo))
+
+	tools := []utils.Tool{
+		{Name: "jq"},
+		{Name: "git"},
+		{Name: "hashicorp/tap/terraform"},
+		{Name: "ripgrep"},
+		{Name: "node", Version: "20"},
+		{Name: "alacritty", Method: "cask"},
+		{Name: "uv", InstallCommand: "curl -LsSf https://astral.sh/uv/install.sh | sh"},
+	}
+	versions, err := ToolVersions(context.Background(), tools)
+	require.NoError(t, err)
+	require.Len(t, versions, len(tools))
+
+	statuses := map[string]string{}
+	for _, v := range versions {
+		statuses[v.Name] = v.Status
+	}
+	assert.Equal(t, map[string]string{
+		"jq":                      StatusOutdated,
+		"git":                     StatusUpToDate,
+		"hashicorp/tap/terraform": StatusPinned,
+		"ripgrep":                 StatusNotInstalled,
+		"node":                    StatusUnknown,
+		"alacritty":               StatusOutdated,
+		"uv":                      StatusUnknown,
+	}, statuses)
+
+	assert.Equal(t, "1.6", versions[0].Installed)
+	assert.Equal(t, "1.7.1", versions[0].Latest)
+	assert.Equal(t, "cask", versions[5].Backend)
+	assert.Equal(t, "custom", versions[6].Backend)
+	mockCmd.AssertExpectations(t)
+}
+
+func TestToolVersionsFallsBackToSingleQueries(t *testing.T) {
+	queried := []string{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		command := args[1]
+		queried = append(queried, command)
+		switch command {
+		case "brew info --json=v2 jq":
+			return exec.Command("echo", formulaeInfo)
+		}
+		return exec.Command("false")
+	}
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	versions, err := ToolVersions(context.Background(), []utils.Tool{{Name: "jq"}, {Name: "does-not-exist"}})
+	require.NoError(t, err)
+	assert.Equal(t, StatusOutdated, versions[0].Status)
+	assert.Equal(t, StatusUnknown, versions[1].Status)
+	assert.Equal(t, "brew info --json=v2 jq does-not-exist", queried[0])
+	assert.Len(t, queried, 3)
+}
+
+func TestUpgradeTools(t *testing.T) {
+	executed := []string{}
+	oldExecCommandContext := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		executed = append(executed, strings.Join(args[1:], " "))
+		return exec.Command("true")
+	}
+	defer func() { execCommandContext = oldExecCommandContext }()
+
+	ios, _, out, _ := iostreams.Test()
+	tools := []utils.Tool{{Name: "jq"}, {Name: "git"}, {Name: "alacritty", Method: "cask"}}
+	stats, err := UpgradeTools(ios, tools, context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"brew upgrade jq git", "brew upgrade --cask alacritty"}, executed)
+	assert.Contains(t, out.String(), "Upgrading jq, git using Homebrew with brew upgrade...")
+	require.Len(t, stats, 3)
+	assert.Equal(t, "Upgrade", stats[0].Operation)
+}
@@ -126,6 +126,14 @@ func NewInstallCmd(iostream *iostreams.IOStreams) *cobra.Command {
 							}
 						}
 					}
+					// RunE is called directly, so flags inherited from the root
+					// command never reach the subcommand; pass them on.
+					if subcmd.Flags().Lookup("non-interactive") != nil {
+						if err := subcmd.Flags().Set("non-interactive", "true"); err != nil {
+							fmt.Fprintf(iostream.ErrOut, "failed to set non-interactive flag: %s\n", err)
+							return err
+						}
+					}
 
 					if err := subcmd.RunE(subcmd, args); err != nil {
 						fmt.Fprintf(iostream.ErrOut, "Error installing %s: %v\n", subcmd.Use, err)
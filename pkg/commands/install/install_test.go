@@ -180,3 +180,29 @@ tools:
 		})
 	}
 }
+
+func TestNewInstallCmdPassesNonInteractive(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+	cmd := NewInstallCmd(ios)
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(configPath, []byte("tools:\n  - name: example-tool\n"), 0644))
+
+	mockSubcommands(cmd, map[string]error{"homebrew": nil, "tools": nil})
+	// xcode would open the installer dialog and wait for it unless it sees
+	// --non-interactive in its own flags.
+	var xcodeNonInteractive bool
+	for _, subcmd := range cmd.Commands() {
+		if subcmd.Use == "xcode" {
+			subcmd.RunE = func(cmd *cobra.Command, args []string) error {
+				xcodeNonInteractive, _ = cmd.Flags().GetBool("non-interactive")
+				return nil
+			}
+		}
+	}
+
+	cmd.Root().CompletionOptions.DisableDefaultCmd = true
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+	cmd.SetArgs([]string{"--non-interactive", "--config", configPath})
+	assert.NoError(t, cmd.Execute())
+	assert.True(t, xcodeNonInteractive)
+}
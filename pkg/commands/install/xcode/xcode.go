@@ -2,6 +2,7 @@ package xcode
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -17,12 +18,26 @@ import (
 
 var execCommandContext = exec.CommandContext
 
-// NewCmdXcode creates a new cobra.Command that installs Xcode on the user's system.
-// The command runs the "xcode-select --install" command, which prompts the user to install Xcode.
-// The command output and errors are forwarded to the user's terminal.
+// pollInterval is how often we check whether the Command Line Tools finished installing.
+var pollInterval = 5 * time.Second
+
+// inProgressMarker makes `softwareupdate -l` list the Command Line Tools even
+// though nothing has requested them yet.
+var inProgressMarker = "/tmp/.com.apple.dt.CommandLineTools.installondemand.in-progress"
+
+var errInstallTimeout = errors.New("timed out waiting for the Command Line Tools installation to finish")
+
+// NewCmdXcode creates a new cobra.Command that installs the Xcode Command Line Tools.
+//
+// By default it runs "xcode-select --install", which opens the macOS installer
+// dialog, and then polls "xcode-select -p" until the tools show up or --timeout
+// expires. With --headless (implied by --non-interactive) it installs the tools
+// through softwareupdate instead, which needs no GUI interaction.
 func NewCmdXcode(iostream *iostreams.IOStreams, statsCollector *utils.StatsCollector) *cobra.Command {
 	cs := iostream.ColorScheme()
 	var stats *utils.Stats
+	var headless, nonInteractive bool
+	var timeout time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "xcode",
@@ -51,24 +66,31 @@ func NewCmdXcode(iostream *iostreams.IOStreams, statsCollector *utils.StatsColle
 				fmt.Println("Xcode is already installed.")
 				return nil // Early exit if Xcode is already installed
 			}
-			installCmd := execCommandContext(ctx, "xcode-select", "--install")
 
-			installCmd.Stdout = os.Stdout
-			installCmd.Stderr = os.Stderr
-			installCmd.Stdin = os.Stdin
+			var err error
+			if headless || nonInteractive {
+				span.SetTag("method", "softwareupdate")
+				err = installHeadless(ctx, iostream)
+			} else {
+				span.SetTag("method", "xcode-select")
+				err = installWithDialog(ctx, iostream, timeout)
+			}
 
-			err := installCmd.Run()
 			duration := time.Since(startTime)
 			stats.Duration = duration
 			if err != nil {
 				fmt.Fprintf(iostream.ErrOut, "Failed to install xcode: %v\n", err)
 				stats.Status = "error"
+				if errors.Is(err, errInstallTimeout) {
+					stats.Status = "timeout"
+				}
 				statsCollector.AddStat(stats)
 
 				span.SetTag("error", true)
 				span.Finish(tracer.WithError(err))
 				return err
 			}
+			fmt.Fprintln(iostream.Out, cs.Green("Xcode Command Line Tools installed successfully."))
 			stats.Status = "success"
 			statsCollector.AddStat(stats)
 
@@ -76,10 +98,108 @@ func NewCmdXcode(iostream *iostreams.IOStreams, statsCollector *utils.StatsColle
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&headless, "headless", false, "Install the Command Line Tools with softwareupdate, without the GUI dialog")
+	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Run in non-interactive mode, implies --headless")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Minute, "How long to wait for the Command Line Tools installation to finish")
 
 	return cmd
 }
 
+// installWithDialog triggers the Command Line Tools installer dialog and waits
+// until the tools are actually installed.
+func installWithDialog(ctx context.Context, iostream *iostreams.IOStreams, timeout time.Duration) error {
+	installCmd := execCommandContext(ctx, "xcode-select", "--install")
+
+	installCmd.Stdout = os.Stdout
+	installCmd.Stderr = os.Stderr
+	installCmd.Stdin = os.Stdin
+
+	if err := installCmd.Run(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(iostream.Out, "Follow the installer dialog to install the Command Line Tools.")
+	iostream.StartProgressIndicatorWithLabel("Waiting for the Command Line Tools installation to finish")
+	defer iostream.StopProgressIndicator()
+	return waitForInstall(ctx, timeout)
+}
+
+// waitForInstall polls xcode-select until the tools are installed, the timeout
+// expires or ctx is cancelled.
+func waitForInstall(ctx context.Context, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return errInstallTimeout
+		case <-ticker.C:
+			if isXcodeAlreadyInstalled(ctx) {
+				return nil
+			}
+		}
+	}
+}
+
+// installHeadless installs the Command Line Tools through softwareupdate, the
+// way unattended provisioning scripts do.
+func installHeadless(ctx context.Context, iostream *iostreams.IOStreams) error {
+	if err := os.WriteFile(inProgressMarker, nil, 0644); err != nil {
+		return fmt.Errorf("failed to create %s: %v", inProgressMarker, err)
+	}
+	defer os.Remove(inProgressMarker)
+
+	var output []byte
+	err := iostream.RunWithProgress("Looking up the Command Line Tools update", func() error {
+		var err error
+		output, err = execCommandContext(ctx, "softwareupdate", "-l").CombinedOutput()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list software updates: %v", err)
+	}
+
+	label := commandLineToolsLabel(string(output))
+	if label == "" {
+		return errors.New("softwareupdate did not offer the Command Line Tools")
+	}
+
+	fmt.Fprintf(iostream.Out, "Installing %s with softwareupdate, enter your password when prompt\n", label)
+	installCmd := execCommandContext(ctx, "sudo", "softwareupdate", "-i", label, "--verbose")
+	installCmd.Stdout = os.Stdout
+	installCmd.Stderr = os.Stderr
+	installCmd.Stdin = os.Stdin
+	if err := installCmd.Run(); err != nil {
+		return err
+	}
+
+	if !isXcodeAlreadyInstalled(ctx) {
+		return errors.New("softwareupdate finished but the Command Line Tools are still missing")
+	}
+	return nil
+}
+
+// commandLineToolsLabel picks the newest Command Line Tools label from
+// `softwareupdate -l` output. Recent macOS versions print "* Label: <name>",
+// older ones "* <name>".
+func commandLineToolsLabel(output string) string {
+	label := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "*") || !strings.Contains(line, "Command Line Tools") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		label = strings.TrimSpace(strings.TrimPrefix(line, "Label:"))
+	}
+	return label
+}
+
 // isXcodeAlreadyInstalled checks if Xcode is already installed by looking for its directory.
 func isXcodeAlreadyInstalled(ctx context.Context) bool {
 	cmd := execCommandContext(ctx, "xcode-select", "-p")
@@ -89,7 +209,6 @@ func isXcodeAlreadyInstalled(ctx context.Context) bool {
 	}
 
 	outputStr := strings.TrimSpace(string(output))
-	fmt.Printf("xcode-select -p output: %s\n", outputStr)
 	// Check output for a known path component, like "/Applications/Xcode.app"
 	// Check for various possible paths
 	knownPaths := []string{
@@ -3,11 +3,17 @@ package xcode
 import (
 	"context"
 	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/XiaoConstantine/mycli/pkg/iostreams"
 	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewCmdXcode(t *testing.T) {
@@ -82,63 +88,176 @@ func TestIsXcodeAlreadyInstalled(t *testing.T) {
 	}
 }
 
+// scriptedExecutor fakes execCommandContext with canned responses. Each command
+// line maps to a list of responses that are consumed in order; the last one is
+// repeated once the list runs out.
+type scriptedExecutor struct {
+	responses map[string][]scriptedResponse
+	calls     []string
+}
+
+type scriptedResponse struct {
+	output string
+	fail   bool
+}
+
+func (s *scriptedExecutor) CommandContext(ctx context.Context, command string, args ...string) *exec.Cmd {
+	line := strings.TrimSpace(command + " " + strings.Join(args, " "))
+	s.calls = append(s.calls, line)
+
+	responses, ok := s.responses[line]
+	if !ok {
+		return exec.Command("false")
+	}
+	response := responses[0]
+	if len(responses) > 1 {
+		s.responses[line] = responses[1:]
+	}
+	if response.fail {
+		return exec.Command("false")
+	}
+	return exec.Command("echo", response.output)
+}
+
+func (s *scriptedExecutor) count(line string) int {
+	n := 0
+	for _, call := range s.calls {
+		if call == line {
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	notInstalled = scriptedResponse{fail: true}
+	installed    = scriptedResponse{output: "/Library/Developer/CommandLineTools"}
+	succeeded    = scriptedResponse{}
+	failed       = scriptedResponse{fail: true}
+)
+
 func TestRunE(t *testing.T) {
-	oldExecCommandContext := execCommandContext
-	defer func() { execCommandContext = oldExecCommandContext }()
+	oldExecCommandContext, oldPollInterval, oldMarker := execCommandContext, pollInterval, inProgressMarker
+	defer func() {
+		execCommandContext, pollInterval, inProgressMarker = oldExecCommandContext, oldPollInterval, oldMarker
+	}()
+	pollInterval = time.Millisecond
+	inProgressMarker = filepath.Join(t.TempDir(), "in-progress")
+
+	softwareUpdateList := scriptedResponse{output: `Software Update Tool
+
+Finding available software
+Software Update found the following new or updated software:
+* Label: Command Line Tools for Xcode-15.3
+	Title: Command Line Tools for Xcode, Version: 15.3, Size: 707501KiB, Recommended: YES,`}
 
 	tests := []struct {
 		name           string
-		mockInstalled  bool
-		mockInstallErr error
-		expectError    bool
+		args           []string
+		responses      map[string][]scriptedResponse
+		expectError    error
+		expectedStatus string
+		expectedCalls  map[string]int
 	}{
 		{
 			name:           "Xcode already installed",
-			mockInstalled:  true,
-			mockInstallErr: nil,
-			expectError:    false,
+			responses:      map[string][]scriptedResponse{"xcode-select -p": {installed}},
+			expectedStatus: "success",
+			expectedCalls:  map[string]int{"xcode-select --install": 0},
+		},
+		{
+			name: "Dialog install waits until the tools show up",
+			responses: map[string][]scriptedResponse{
+				"xcode-select -p":        {notInstalled, notInstalled, notInstalled, installed},
+				"xcode-select --install": {succeeded},
+			},
+			expectedStatus: "success",
+			expectedCalls:  map[string]int{"xcode-select -p": 4, "xcode-select --install": 1},
+		},
+		{
+			name: "Dialog install fails to start",
+			responses: map[string][]scriptedResponse{
+				"xcode-select -p":        {notInstalled},
+				"xcode-select --install": {failed},
+			},
+			expectError:    errors.New("exit status 1"),
+			expectedStatus: "error",
+		},
+		{
+			name: "Dialog install times out",
+			args: []string{"--timeout", "20ms"},
+			responses: map[string][]scriptedResponse{
+				"xcode-select -p":        {notInstalled},
+				"xcode-select --install": {succeeded},
+			},
+			expectError:    errInstallTimeout,
+			expectedStatus: "timeout",
 		},
 		{
-			name:           "Xcode not installed, installation succeeds",
-			mockInstalled:  false,
-			mockInstallErr: nil,
-			expectError:    false,
+			name: "Headless install through softwareupdate",
+			args: []string{"--headless"},
+			responses: map[string][]scriptedResponse{
+				"xcode-select -p":   {notInstalled, installed},
+				"softwareupdate -l": {softwareUpdateList},
+				"sudo softwareupdate -i Command Line Tools for Xcode-15.3 --verbose": {succeeded},
+			},
+			expectedStatus: "success",
+			expectedCalls:  map[string]int{"xcode-select --install": 0, "sudo softwareupdate -i Command Line Tools for Xcode-15.3 --verbose": 1},
 		},
 		{
-			name:           "Xcode not installed, installation fails",
-			mockInstalled:  false,
-			mockInstallErr: errors.New("installation failed"),
-			expectError:    true,
+			name: "Headless install with nothing to install",
+			args: []string{"--headless"},
+			responses: map[string][]scriptedResponse{
+				"xcode-select -p":   {notInstalled},
+				"softwareupdate -l": {{output: "No new software available."}},
+			},
+			expectError:    errors.New("softwareupdate did not offer the Command Line Tools"),
+			expectedStatus: "error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			execCommandContext = func(ctx context.Context, command string, args ...string) *exec.Cmd {
-				if command == "xcode-select" && args[0] == "-p" {
-					if tt.mockInstalled {
-						return exec.Command("echo", "/Applications/Xcode.app/Contents/Developer")
-					}
-					return exec.Command("false")
-				}
-				if command == "xcode-select" && args[0] == "--install" {
-					if tt.mockInstallErr != nil {
-						return exec.Command("false")
-					}
-					return exec.Command("true")
-				}
-				return exec.Command("echo", "unexpected command")
-			}
+			executor := &scriptedExecutor{responses: tt.responses}
+			execCommandContext = executor.CommandContext
 
 			ios, _, _, _ := iostreams.Test()
 			statsCollector := utils.NewStatsCollector()
 
 			cmd := NewCmdXcode(ios, statsCollector)
-			err := cmd.RunE(cmd, []string{})
+			cmd.SetArgs(tt.args)
+			cmd.SetContext(context.Background())
+			err := cmd.Execute()
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
 
-			if (err != nil) != tt.expectError {
-				t.Errorf("Expected error: %v, got: %v", tt.expectError, err)
+			stats := statsCollector.GetStats()
+			require.Len(t, stats, 1)
+			assert.Equal(t, tt.expectedStatus, stats[0].Status)
+			for line, n := range tt.expectedCalls {
+				assert.Equal(t, n, executor.count(line), "calls of %q", line)
 			}
+			_, statErr := os.Stat(inProgressMarker)
+			assert.True(t, os.IsNotExist(statErr), "in-progress marker should be cleaned up")
 		})
 	}
 }
+
+func TestCommandLineToolsLabel(t *testing.T) {
+	older := `Software Update found the following new or updated software:
+   * Command Line Tools (macOS Mojave version 10.14) for Xcode-10.3
+	Command Line Tools (macOS Mojave version 10.14) for Xcode (10.3), 199140K [recommended]`
+	assert.Equal(t, "Command Line Tools (macOS Mojave version 10.14) for Xcode-10.3", commandLineToolsLabel(older))
+
+	newer := `* Label: Command Line Tools for Xcode-14.3
+	Title: Command Line Tools for Xcode, Version: 14.3
+* Label: Command Line Tools for Xcode-15.3
+	Title: Command Line Tools for Xcode, Version: 15.3`
+	assert.Equal(t, "Command Line Tools for Xcode-15.3", commandLineToolsLabel(newer))
+
+	assert.Equal(t, "", commandLineToolsLabel("No new software available."))
+}
@@ -11,9 +11,12 @@ import (
 	"path/filepath"
 
 	"github.com/XiaoConstantine/mycli/pkg/build"
+	"github.com/XiaoConstantine/mycli/pkg/commands/config"
 	"github.com/XiaoConstantine/mycli/pkg/commands/extensions"
 	"github.com/XiaoConstantine/mycli/pkg/commands/install"
+	"github.com/XiaoConstantine/mycli/pkg/commands/uninstall"
 	"github.com/XiaoConstantine/mycli/pkg/commands/update"
+	"github.com/XiaoConstantine/mycli/pkg/commands/upgrade"
 	"github.com/XiaoConstantine/mycli/pkg/iostreams"
 	"github.com/XiaoConstantine/mycli/pkg/utils"
 
@@ -93,12 +96,20 @@ func NewRootCmd(iostream *iostreams.IOStreams) (*cobra.Command, error) {
 	})
 
 	installCmd := install.NewInstallCmd(iostream)
+	uninstallCmd := uninstall.NewUninstallCmd(iostream)
 	configureCmd := configure.NewConfigureCmd(iostream)
+	configCmd := config.NewConfigCmd(iostream)
 	updateCmd := update.NewUpdateCmd(iostream)
+	outdatedCmd := upgrade.NewOutdatedCmd(iostream)
+	upgradeCmd := upgrade.NewUpgradeCmd(iostream)
 
 	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(configureCmd)
+	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(upgradeCmd)
 
 	// Add extension management command
 	extCmd := extensions.NewCmdExtension(iostream)
@@ -132,7 +143,7 @@ func Run(args []string) ExitCode {
 	}
 
 	// Check for updates
-	hasUpdate, latestVersion, err := update.CheckForUpdatesFunc(iostream)
+	hasUpdate, latestVersion, err := update.CheckForUpdatesFunc(ctx, iostream)
 	if err != nil {
 		fmt.Fprintf(stderr, "Failed to check for updates: %s\n", err)
 	} else if hasUpdate {
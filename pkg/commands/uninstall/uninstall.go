@@ -0,0 +1,132 @@
+/*
+Package uninstall provides commands that undo what `mycli install` set up.
+*/
+package uninstall
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/XiaoConstantine/mycli/pkg/commands/install/homebrew"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// NewUninstallCmd creates and returns a cobra.Command for the 'uninstall' command of mycli.
+//
+// Usage:
+//
+//	mycli uninstall sources [flags]
+//	mycli uninstall snippets [name...] [flags]
+func NewUninstallCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove things installed by mycli",
+		Annotations: map[string]string{
+			"group": "install",
+		},
+	}
+
+	cmd.AddCommand(newUninstallSourcesCmd(iostream))
+	cmd.AddCommand(newUninstallSnippetsCmd(iostream))
+	return cmd
+}
+
+func newUninstallSourcesCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cs := iostream.ColorScheme()
+	var configFile string
+
+	cmd := &cobra.Command{
+		Use:   "sources",
+		Short: "Remove the brew taps and apt/dnf repositories declared in the config",
+		Annotations: map[string]string{
+			"group": "install",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "uninstall_sources")
+			defer span.Finish()
+			homebrew.UseRememberedPrefix()
+
+			config, err := utils.LoadToolsConfig(configFile)
+			if err != nil {
+				fmt.Fprintf(iostream.ErrOut, cs.Red("Error loading configuration: %v\n"), err)
+				return utils.ConfigNotFoundError
+			}
+
+			stats, err := homebrew.RemoveSources(iostream, config.Sources, ctx)
+			utils.PrintCombinedStats(iostream, stats)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	return cmd
+}
+
+func newUninstallSnippetsCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cs := iostream.ColorScheme()
+	var configFile string
+
+	cmd := &cobra.Command{
+		Use:   "snippets [name...]",
+		Short: "Remove the shell_snippets blocks of the tools in the config from your shell rc file",
+		Annotations: map[string]string{
+			"group": "install",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, _ := tracer.StartSpanFromContext(cmd.Context(), "uninstall_snippets")
+			defer span.Finish()
+
+			config, err := utils.LoadToolsConfig(configFile)
+			if err != nil {
+				fmt.Fprintf(iostream.ErrOut, cs.Red("Error loading configuration: %v\n"), err)
+				return utils.ConfigNotFoundError
+			}
+			rc, err := utils.DefaultShellRC()
+			if err != nil {
+				return err
+			}
+
+			stats, err := removeSnippets(rc, config.Tools, args)
+			if len(stats) > 0 {
+				utils.PrintCombinedStats(iostream, stats)
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	return cmd
+}
+
+// removeSnippets removes the managed blocks of the named tools from rc, or of
+// every tool with shell_snippets when no names are given.
+func removeSnippets(rc string, tools []utils.Tool, names []string) ([]*utils.Stats, error) {
+	if len(names) == 0 {
+		for _, tool := range tools {
+			if len(tool.ShellSnippets) > 0 || len(tool.Env) > 0 || len(tool.Paths) > 0 {
+				names = append(names, tool.Name)
+			}
+		}
+	}
+
+	var stats []*utils.Stats
+	for _, name := range names {
+		start := time.Now()
+		stat := &utils.Stats{Name: name, Operation: "Uninstall snippets"}
+		stats = append(stats, stat)
+		changed, err := utils.RemoveManagedBlock(rc, name)
+		stat.Duration = time.Since(start)
+		switch {
+		case err != nil:
+			stat.Status = "error"
+			return stats, err
+		case changed:
+			stat.Status = "success"
+		default:
+			stat.Status = "not present"
+		}
+	}
+	return stats, nil
+}
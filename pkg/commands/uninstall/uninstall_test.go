@@ -0,0 +1,59 @@
+package uninstall
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUninstallCmd(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+	cmd := NewUninstallCmd(ios)
+
+	assert.Equal(t, "uninstall", cmd.Use)
+	assert.Equal(t, "install", cmd.Annotations["group"])
+	assert.Contains(t, utils.GetSubcommandNames(cmd), "sources")
+	assert.Contains(t, utils.GetSubcommandNames(cmd), "snippets [name...]")
+}
+
+func TestUninstallSourcesMissingConfig(t *testing.T) {
+	ios, _, _, errOut := iostreams.Test()
+	cmd := NewUninstallCmd(ios)
+	cmd.SetArgs([]string{"sources", "--config", "does-not-exist.yaml"})
+
+	err := cmd.Execute()
+	assert.Equal(t, utils.ConfigNotFoundError, err)
+	assert.Contains(t, errOut.String(), "Error loading configuration")
+}
+
+func TestRemoveSnippets(t *testing.T) {
+	rc := filepath.Join(t.TempDir(), ".zshrc")
+	content := utils.UpsertBlock(utils.UpsertBlock("# mine\n", "zoxide", "zoxide"), "fzf", "fzf")
+	require.NoError(t, os.WriteFile(rc, []byte(content), 0644))
+	tools := []utils.Tool{
+		{Name: "zoxide", ShellSnippets: []string{"zoxide"}},
+		{Name: "fzf", ShellSnippets: []string{"fzf"}},
+		{Name: "git"},
+	}
+
+	stats, err := removeSnippets(rc, tools, []string{"fzf", "bat"})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "success", stats[0].Status)
+	assert.Equal(t, "not present", stats[1].Status)
+
+	stats, err = removeSnippets(rc, tools, nil)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "zoxide", stats[0].Name)
+	assert.Equal(t, "success", stats[0].Status)
+
+	got, err := os.ReadFile(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "# mine\n", string(got))
+}
@@ -3,7 +3,9 @@ package update
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,7 +30,12 @@ var CheckForUpdatesFunc = CheckForUpdates
 //
 // Usage:
 //
-//	mycli update
+//	mycli update [flags]
+//
+// Flags:
+//
+//	-c, --config string   Configuration file whose auth block is used for the
+//	                      download, if it exists (default "config.yaml")
 //
 // The function sets up the command's flags and its Run function. It uses the provided
 // IOStreams for input/output operations and interacts with the user to confirm the update.
@@ -45,6 +52,7 @@ var CheckForUpdatesFunc = CheckForUpdates
 //	updateCmd := update.UpdateCmd(iostreams.System())
 //	rootCmd.AddCommand(updateCmd)
 func NewUpdateCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	var configFile string
 	cmd := &cobra.Command{
 		Use:   "update",
 		Short: "Update mycli to the latest version",
@@ -54,17 +62,29 @@ func NewUpdateCmd(iostream *iostreams.IOStreams) *cobra.Command {
 				return err
 			}
 
-			if err := ensurePathInZshrc(installDir); err != nil {
+			if err := ensurePathInShellRC(installDir); err != nil {
 				return err
 			}
 
-			return updateCLI(iostream)
+			ctx := cmd.Context()
+			// Releases of a private fork may need the credentials of the
+			// config's auth block; without a config, GH_TOKEN and ~/.netrc
+			// still apply.
+			if _, err := os.Stat(configFile); err == nil {
+				config, err := utils.LoadToolsConfig(configFile)
+				if err != nil {
+					return fmt.Errorf("failed to load %s: %w", configFile, err)
+				}
+				ctx = utils.WithAuth(ctx, config.Auth)
+			}
+			return updateCLI(ctx, iostream)
 		},
 	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
 	return cmd
 }
 
-func updateCLI(iostream *iostreams.IOStreams) error {
+func updateCLI(ctx context.Context, iostream *iostreams.IOStreams) error {
 	currentVersion := build.Version
 
 	// Ensure .mycli/bin directory exists
@@ -74,7 +94,7 @@ func updateCLI(iostream *iostreams.IOStreams) error {
 	}
 
 	// Get the latest release info
-	release, err := getLatestRelease()
+	release, err := getLatestRelease(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get latest release: %w", err)
 	}
@@ -98,9 +118,9 @@ func updateCLI(iostream *iostreams.IOStreams) error {
 		return fmt.Errorf("no suitable release found for %s/%s", runtime.GOOS, runtime.GOARCH)
 	}
 
-	fmt.Fprintf(iostream.Out, "Downloading %s\n", assetURL)
+	fmt.Fprintf(iostream.Out, "Downloading %s\n", utils.RedactURL(assetURL))
 	// Download the new binary
-	resp, err := http.Get(assetURL)
+	resp, err := get(ctx, assetURL)
 	if err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}
@@ -151,26 +171,15 @@ func updateCLI(iostream *iostreams.IOStreams) error {
 		}
 
 		if header.Name == "mycli" {
-			// Create a new temporary file for the extracted binary
-			extractedTmp, err := os.CreateTemp("", "mycli-extracted")
+			// Extract next to the old binary and rename over it, so a failed
+			// download never leaves a truncated mycli behind.
+			err := utils.WriteAtomic(installPath, 0755, func(f *os.File) error {
+				if _, err := io.Copy(f, tr); err != nil {
+					return fmt.Errorf("failed to extract binary: %w", err)
+				}
+				return f.Chmod(0755)
+			})
 			if err != nil {
-				return fmt.Errorf("failed to create temporary file for extracted binary: %w", err)
-			}
-			defer os.Remove(extractedTmp.Name())
-
-			// Copy the file contents
-			if _, err := io.Copy(extractedTmp, tr); err != nil {
-				return fmt.Errorf("failed to extract binary: %w", err)
-			}
-			extractedTmp.Close()
-
-			// Make the temporary file executable
-			if err := os.Chmod(extractedTmp.Name(), 0755); err != nil {
-				return fmt.Errorf("failed to make new binary executable: %w", err)
-			}
-
-			// Replace the old binary with the new one
-			if err := os.Rename(extractedTmp.Name(), installPath); err != nil {
 				return fmt.Errorf("failed to replace old binary: %w", err)
 			}
 
@@ -195,33 +204,35 @@ var ensureInstallDirectory = func() (string, error) {
 	return installDir, nil
 }
 
-func ensurePathInZshrc(installDir string) error {
+// ensurePathInShellRC adds installDir to PATH in the rc file of the user's
+// login shell.
+func ensurePathInShellRC(installDir string) error {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("failed to get user home directory: %w", err)
 	}
 
-	zshrcPath := filepath.Join(home, ".zshrc")
-	zshrcContent, err := os.ReadFile(zshrcPath)
+	shell := utils.DetectShell()
+	rcFile := shell.RCFile(runtime.GOOS)
+	rcPath := filepath.Join(home, rcFile)
+	rcContent, err := os.ReadFile(rcPath)
 	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to read .zshrc: %w", err)
+		return fmt.Errorf("failed to read %s: %w", rcFile, err)
 	}
 
-	pathLine := fmt.Sprintf("export PATH=\"$PATH:%s\"", installDir)
-	if !strings.Contains(string(zshrcContent), pathLine) {
-		f, err := os.OpenFile(zshrcPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("failed to open .zshrc: %w", err)
-		}
-		defer f.Close()
-
-		if _, err := f.WriteString("\n" + pathLine + "\n"); err != nil {
-			return fmt.Errorf("failed to write to .zshrc: %w", err)
-		}
-
-		fmt.Println("Added .mycli/bin to your PATH in .zshrc. Please restart your terminal or run 'source ~/.zshrc' to apply the changes.")
+	// Older versions of mycli appended the export outside a managed block.
+	pathLine := shell.PathLine(installDir, false)
+	if strings.Contains(utils.StripBlocks(string(rcContent)), pathLine) {
+		return nil
 	}
 
+	changed, err := utils.SetManagedBlock(rcPath, "mycli", pathLine)
+	if err != nil {
+		return err
+	}
+	if changed {
+		fmt.Printf("Added .mycli/bin to your PATH in %s. Please restart your terminal or run 'source ~/%s' to apply the changes.\n", rcFile, rcFile)
+	}
 	return nil
 }
 
@@ -237,8 +248,23 @@ type githubAsset struct {
 	BrowserDownloadURL string `json:"browser_download_url"`
 }
 
-var getLatestRelease = func() (*githubRelease, error) {
-	resp, err := http.Get(repoURL)
+// get fetches rawURL with the credentials configured for its host, so releases
+// of private forks can be downloaded with GITHUB_TOKEN, ~/.netrc or the auth
+// block ctx carries (see utils.WithAuth).
+func get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := utils.NewRequest(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.New(utils.Redact(err.Error()))
+	}
+	return resp, nil
+}
+
+var getLatestRelease = func(ctx context.Context) (*githubRelease, error) {
+	resp, err := get(ctx, repoURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
 	}
@@ -256,11 +282,11 @@ var getLatestRelease = func() (*githubRelease, error) {
 	return &release, nil
 }
 
-func CheckForUpdates(iostream *iostreams.IOStreams) (bool, string, error) {
+func CheckForUpdates(ctx context.Context, iostream *iostreams.IOStreams) (bool, string, error) {
 	currentVersion := build.Version
 
 	// Get the latest release info
-	release, err := getLatestRelease()
+	release, err := getLatestRelease(ctx)
 	if err != nil {
 		return false, "", fmt.Errorf("failed to get latest release: %w", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/XiaoConstantine/mycli/pkg/build"
 	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,7 +39,7 @@ func TestCheckForUpdates(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Set up the mock
-			getLatestRelease = func() (*githubRelease, error) {
+			getLatestRelease = func(ctx context.Context) (*githubRelease, error) {
 				return &githubRelease{TagName: tc.latestVersion}, nil
 			}
 
@@ -46,7 +48,7 @@ func TestCheckForUpdates(t *testing.T) {
 
 			ios, _, _, _ := iostreams.Test()
 
-			hasUpdate, latestVersion, err := CheckForUpdates(ios)
+			hasUpdate, latestVersion, err := CheckForUpdates(context.Background(), ios)
 
 			if tc.expectError {
 				assert.Error(t, err)
@@ -124,7 +126,7 @@ func TestUpdateCLI(t *testing.T) {
 	// Mock getLatestRelease function
 	originalGetLatestRelease := getLatestRelease
 	defer func() { getLatestRelease = originalGetLatestRelease }()
-	getLatestRelease = func() (*githubRelease, error) {
+	getLatestRelease = func(ctx context.Context) (*githubRelease, error) {
 		return &githubRelease{
 			TagName: "v1.2.3",
 			Assets: []githubAsset{
@@ -144,7 +146,7 @@ func TestUpdateCLI(t *testing.T) {
 	ios, _, out, _ := iostreams.Test()
 
 	// Run updateCLI
-	err = updateCLI(ios)
+	err = updateCLI(context.Background(), ios)
 	assert.NoError(t, err)
 
 	// Check if the binary was updated
@@ -160,7 +162,7 @@ func TestUpdateCLINoUpdateNeeded(t *testing.T) {
 	// Mock getLatestRelease function
 	originalGetLatestRelease := getLatestRelease
 	defer func() { getLatestRelease = originalGetLatestRelease }()
-	getLatestRelease = func() (*githubRelease, error) {
+	getLatestRelease = func(ctx context.Context) (*githubRelease, error) {
 		return &githubRelease{
 			TagName: "v1.0.0",
 		}, nil
@@ -173,9 +175,60 @@ func TestUpdateCLINoUpdateNeeded(t *testing.T) {
 	ios, _, out, _ := iostreams.Test()
 
 	// Run updateCLI
-	err := updateCLI(ios)
+	err := updateCLI(context.Background(), ios)
 	assert.NoError(t, err)
 
 	// Check the output message
 	assert.Contains(t, out.String(), "You're already using the latest version of mycli.")
 }
+
+func TestEnsurePathInShellRC(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/zsh")
+	zshrc := filepath.Join(home, ".zshrc")
+	installDir := filepath.Join(home, ".mycli", "bin")
+
+	// Running twice leaves a single managed block.
+	assert.NoError(t, ensurePathInShellRC(installDir))
+	assert.NoError(t, ensurePathInShellRC(installDir))
+	content, err := os.ReadFile(zshrc)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("# >>> mycli: mycli >>>\nexport PATH=\"$PATH:%s\"\n# <<< mycli: mycli <<<\n", installDir), string(content))
+
+	// A line added by an older version is left as is.
+	legacy := fmt.Sprintf("\nexport PATH=\"$PATH:%s\"\n", installDir)
+	assert.NoError(t, os.WriteFile(zshrc, []byte(legacy), 0644))
+	assert.NoError(t, ensurePathInShellRC(installDir))
+	content, err = os.ReadFile(zshrc)
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, string(content))
+}
+
+func TestEnsurePathInShellRCFish(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/usr/bin/fish")
+	installDir := filepath.Join(home, ".mycli", "bin")
+
+	assert.NoError(t, ensurePathInShellRC(installDir))
+	content, err := os.ReadFile(filepath.Join(home, ".config", "fish", "config.fish"))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("# >>> mycli: mycli >>>\nset -gx PATH $PATH \"%s\"\n# <<< mycli: mycli <<<\n", installDir), string(content))
+}
+
+func TestGetUsesConfiguredAuth(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("RELEASE_TOKEN", "release-token")
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	ctx := utils.WithAuth(context.Background(), []utils.HostAuth{{Host: "127.0.0.1", TokenEnv: "RELEASE_TOKEN"}})
+	resp, err := get(ctx, server.URL+"/releases/latest")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "Bearer release-token", authorization)
+}
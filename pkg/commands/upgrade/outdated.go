@@ -0,0 +1,109 @@
+/*
+Package upgrade provides the outdated and upgrade commands, which keep the tools
+declared in the config current after the initial bootstrap.
+*/
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/XiaoConstantine/mycli/pkg/commands/install/homebrew"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var toolVersions = homebrew.ToolVersions
+
+// NewOutdatedCmd creates and returns a cobra.Command for the 'outdated' command of mycli.
+//
+// The outdated command lists every tool in the config with its installed and
+// latest available version.
+//
+// Usage:
+//
+//	mycli outdated [flags]
+//
+// Flags:
+//
+//	-c, --config string   Path to the configuration file (default "config.yaml")
+//	--json                Print the result as JSON
+func NewOutdatedCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cs := iostream.ColorScheme()
+	var configFile string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "List installed and latest versions of configured tools",
+		Annotations: map[string]string{
+			"group": "update",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "outdated")
+			defer span.Finish()
+			homebrew.UseRememberedPrefix()
+
+			config, err := utils.LoadToolsConfig(configFile)
+			if err != nil {
+				fmt.Fprintf(iostream.ErrOut, cs.Red("Error loading configuration: %v\n"), err)
+				return utils.ConfigNotFoundError
+			}
+
+			versions, err := toolVersions(ctx, config.Tools)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				return printVersionsJSON(iostream, versions)
+			}
+			printVersionsTable(iostream, versions)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output in JSON format")
+	return cmd
+}
+
+func printVersionsJSON(iostream *iostreams.IOStreams, versions []homebrew.ToolVersion) error {
+	encoder := json.NewEncoder(iostream.Out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(versions)
+}
+
+func printVersionsTable(iostream *iostreams.IOStreams, versions []homebrew.ToolVersion) {
+	cs := iostream.ColorScheme()
+	table := tablewriter.NewWriter(iostream.Out)
+	table.SetHeader([]string{"Name", "Backend", "Installed", "Latest", "Status"})
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.FgGreenColor},
+		tablewriter.Colors{tablewriter.FgGreenColor},
+	)
+
+	for _, v := range versions {
+		color := cs.Green
+		switch v.Status {
+		case homebrew.StatusOutdated, homebrew.StatusNotInstalled:
+			color = cs.Yellow
+		case homebrew.StatusPinned, homebrew.StatusUnknown:
+			color = cs.Gray
+		}
+		table.Append([]string{color(v.Name), color(v.Backend), color(orDash(v.Installed)), color(orDash(v.Latest)), color(v.Status)})
+	}
+	table.Render()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+package upgrade
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/commands/install/homebrew"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var upgradeTools = homebrew.UpgradeTools
+
+// NewUpgradeCmd creates and returns a cobra.Command for the 'upgrade' command of mycli.
+//
+// The upgrade command upgrades outdated tools from the config. Without arguments
+// every outdated tool is upgraded; otherwise only the named ones. Tools pinned to
+// a version in the config, and formulae held with `brew pin`, are never upgraded.
+//
+// Usage:
+//
+//	mycli upgrade [tool...] [flags]
+//
+// Flags:
+//
+//	-c, --config string   Path to the configuration file (default "config.yaml")
+//	--json                Print the upgrade results as JSON
+func NewUpgradeCmd(iostream *iostreams.IOStreams) *cobra.Command {
+	cs := iostream.ColorScheme()
+	var configFile string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "upgrade [tool...]",
+		Short: "Upgrade configured tools to their latest versions",
+		Annotations: map[string]string{
+			"group": "update",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			span, ctx := tracer.StartSpanFromContext(cmd.Context(), "upgrade")
+			defer span.Finish()
+			homebrew.UseRememberedPrefix()
+
+			config, err := utils.LoadToolsConfig(configFile)
+			if err != nil {
+				fmt.Fprintf(iostream.ErrOut, cs.Red("Error loading configuration: %v\n"), err)
+				return utils.ConfigNotFoundError
+			}
+
+			tools, err := selectTools(config.Tools, args)
+			if err != nil {
+				return err
+			}
+
+			versions, err := toolVersions(ctx, tools)
+			if err != nil {
+				return err
+			}
+
+			var toUpgrade []utils.Tool
+			for i, v := range versions {
+				switch v.Status {
+				case homebrew.StatusOutdated:
+					toUpgrade = append(toUpgrade, tools[i])
+				case homebrew.StatusPinned:
+					fmt.Fprintf(iostream.Out, "Skipping %s: pinned at %s\n", v.Name, v.Installed)
+				case homebrew.StatusUnknown:
+					fmt.Fprintf(iostream.Out, "Skipping %s: version can't be determined\n", v.Name)
+				case homebrew.StatusNotInstalled:
+					fmt.Fprintf(iostream.Out, "Skipping %s: not installed, run 'mycli install tools' first\n", v.Name)
+				}
+			}
+
+			skipped := skippedSummary(versions)
+			if len(toUpgrade) == 0 {
+				if skipped == "" {
+					fmt.Fprintln(iostream.Out, cs.Green("All tools are up to date."))
+				} else {
+					fmt.Fprintf(iostream.Out, "Nothing to upgrade: %s.\n", skipped)
+				}
+				return nil
+			}
+
+			stats, err := upgradeTools(iostream, toUpgrade, ctx)
+			if asJSON {
+				if jsonErr := printVersionsJSON(iostream, upgradedVersions(versions, stats)); jsonErr != nil {
+					return jsonErr
+				}
+			} else {
+				utils.PrintCombinedStats(iostream, stats)
+				if skipped != "" {
+					fmt.Fprintf(iostream.Out, "Skipped: %s.\n", skipped)
+				}
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Path to the configuration file")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output in JSON format")
+	return cmd
+}
+
+// selectTools returns the tools named in args, or all tools when args is empty.
+func selectTools(tools []utils.Tool, args []string) ([]utils.Tool, error) {
+	if len(args) == 0 {
+		return tools, nil
+	}
+	byName := make(map[string]utils.Tool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+	selected := make([]utils.Tool, 0, len(args))
+	for _, name := range args {
+		tool, ok := byName[name]
+		if !ok {
+			return nil, utils.FlagErrorf("tool %s is not in the config", name)
+		}
+		selected = append(selected, tool)
+	}
+	return selected, nil
+}
+
+// skippedSummary counts the tools upgrade leaves alone by the reason, e.g.
+// "1 pinned, 2 unknown", or returns "" when it skips none.
+func skippedSummary(versions []homebrew.ToolVersion) string {
+	counts := make(map[string]int)
+	for _, v := range versions {
+		counts[v.Status]++
+	}
+	var parts []string
+	for _, status := range []string{homebrew.StatusPinned, homebrew.StatusNotInstalled, homebrew.StatusUnknown} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// upgradedVersions reports, for each tool that was upgraded, its status after the run.
+func upgradedVersions(versions []homebrew.ToolVersion, stats []*utils.Stats) []homebrew.ToolVersion {
+	byName := make(map[string]homebrew.ToolVersion, len(versions))
+	for _, v := range versions {
+		byName[v.Name] = v
+	}
+	result := make([]homebrew.ToolVersion, 0, len(stats))
+	for _, stat := range stats {
+		v := byName[stat.Name]
+		if stat.Status == "success" {
+			v.Installed = v.Latest
+			v.Status = homebrew.StatusUpToDate
+		}
+		result = append(result, v)
+	}
+	return result
+}
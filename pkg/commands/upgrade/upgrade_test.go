@@ -0,0 +1,139 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/commands/install/homebrew"
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/XiaoConstantine/mycli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+tools:
+  - name: jq
+  - name: gh
+    method: github-release
+    repo: cli/cli
+    version: v2.60.0
+  - name: alacritty
+    method: cask
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func stubVersions(t *testing.T) {
+	oldToolVersions := toolVersions
+	toolVersions = func(ctx context.Context, tools []utils.Tool) ([]homebrew.ToolVersion, error) {
+		all := map[string]homebrew.ToolVersion{
+			"jq":        {Name: "jq", Backend: "brew", Installed: "1.6", Latest: "1.7.1", Status: homebrew.StatusOutdated},
+			"gh":        {Name: "gh", Backend: "github-release", Installed: "v2.60.0", Latest: "v2.62.0", Pinned: true, Status: homebrew.StatusPinned},
+			"alacritty": {Name: "alacritty", Backend: "cask", Installed: "0.13.2", Latest: "0.13.2", Status: homebrew.StatusUpToDate},
+		}
+		var versions []homebrew.ToolVersion
+		for _, tool := range tools {
+			versions = append(versions, all[tool.Name])
+		}
+		return versions, nil
+	}
+	t.Cleanup(func() { toolVersions = oldToolVersions })
+}
+
+func TestOutdatedCmd(t *testing.T) {
+	stubVersions(t)
+	configPath := writeConfig(t)
+
+	t.Run("table", func(t *testing.T) {
+		ios, _, out, _ := iostreams.Test()
+		cmd := NewOutdatedCmd(ios)
+		cmd.SetArgs([]string{"--config", configPath})
+		require.NoError(t, cmd.Execute())
+
+		assert.Contains(t, out.String(), "INSTALLED")
+		assert.Contains(t, out.String(), "1.7.1")
+		assert.Contains(t, out.String(), "pinned")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ios, _, out, _ := iostreams.Test()
+		cmd := NewOutdatedCmd(ios)
+		cmd.SetArgs([]string{"--config", configPath, "--json"})
+		require.NoError(t, cmd.Execute())
+
+		var versions []homebrew.ToolVersion
+		require.NoError(t, json.Unmarshal(out.Bytes(), &versions))
+		require.Len(t, versions, 3)
+		assert.Equal(t, homebrew.StatusOutdated, versions[0].Status)
+	})
+}
+
+func TestUpgradeCmd(t *testing.T) {
+	stubVersions(t)
+	configPath := writeConfig(t)
+
+	var upgraded []string
+	oldUpgradeTools := upgradeTools
+	upgradeTools = func(iostream *iostreams.IOStreams, tools []utils.Tool, ctx context.Context) ([]*utils.Stats, error) {
+		var stats []*utils.Stats
+		for _, tool := range tools {
+			upgraded = append(upgraded, tool.Name)
+			stats = append(stats, &utils.Stats{Name: tool.Name, Operation: "Upgrade", Status: "success"})
+		}
+		return stats, nil
+	}
+	defer func() { upgradeTools = oldUpgradeTools }()
+
+	t.Run("all outdated tools", func(t *testing.T) {
+		upgraded = nil
+		ios, _, out, _ := iostreams.Test()
+		cmd := NewUpgradeCmd(ios)
+		cmd.SetArgs([]string{"--config", configPath})
+		require.NoError(t, cmd.Execute())
+
+		assert.Equal(t, []string{"jq"}, upgraded)
+		assert.Contains(t, out.String(), "Skipping gh: pinned at v2.60.0")
+		assert.Contains(t, out.String(), "Skipped: 1 pinned.")
+	})
+
+	t.Run("named pinned tool is not upgraded", func(t *testing.T) {
+		upgraded = nil
+		ios, _, out, _ := iostreams.Test()
+		cmd := NewUpgradeCmd(ios)
+		cmd.SetArgs([]string{"--config", configPath, "gh"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Empty(t, upgraded)
+		assert.Contains(t, out.String(), "Nothing to upgrade: 1 pinned.")
+		assert.NotContains(t, out.String(), "up to date")
+	})
+
+	t.Run("up to date tool", func(t *testing.T) {
+		upgraded = nil
+		ios, _, out, _ := iostreams.Test()
+		cmd := NewUpgradeCmd(ios)
+		cmd.SetArgs([]string{"--config", configPath, "alacritty"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Empty(t, upgraded)
+		assert.Contains(t, out.String(), "All tools are up to date.")
+	})
+
+	t.Run("unknown tool", func(t *testing.T) {
+		ios, _, _, _ := iostreams.Test()
+		cmd := NewUpgradeCmd(ios)
+		cmd.SetArgs([]string{"--config", configPath, "nope"})
+		cmd.SilenceErrors = true
+		err := cmd.Execute()
+
+		var flagErr *utils.FlagError
+		assert.ErrorAs(t, err, &flagErr)
+	})
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("New file", func(t *testing.T) {
+		path := filepath.Join(dir, "new")
+		require.NoError(t, WriteFileAtomic(path, []byte("hello\n"), 0600))
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", string(content))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("Existing file keeps its mode", func(t *testing.T) {
+		path := filepath.Join(dir, "existing")
+		require.NoError(t, os.WriteFile(path, []byte("old\n"), 0640))
+		require.NoError(t, os.Chmod(path, 0640))
+		require.NoError(t, WriteFileAtomic(path, []byte("new\n"), 0644))
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "new\n", string(content))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+
+	t.Run("Symlink is replaced", func(t *testing.T) {
+		target := filepath.Join(dir, "target")
+		require.NoError(t, os.WriteFile(target, []byte("target\n"), 0644))
+		path := filepath.Join(dir, "link")
+		require.NoError(t, os.Symlink(target, path))
+		require.NoError(t, WriteFileAtomic(path, []byte("file\n"), 0644))
+		info, err := os.Lstat(path)
+		require.NoError(t, err)
+		assert.True(t, info.Mode().IsRegular())
+		content, err := os.ReadFile(target)
+		require.NoError(t, err)
+		assert.Equal(t, "target\n", string(content), "the link target should be untouched")
+	})
+}
+
+func TestWriteAtomicFailureKeepsOldContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "init.lua")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0644))
+
+	err := WriteAtomic(path, 0644, func(f *os.File) error {
+		if _, err := f.Write([]byte("partial")); err != nil {
+			return err
+		}
+		return errors.New("connection reset")
+	})
+	assert.EqualError(t, err, "connection reset")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old\n", string(content))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the temporary file should be removed")
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// githubHosts share GitHub credentials: a token for github.com is also sent to
+// raw.githubusercontent.com, where converted blob URLs are downloaded from.
+var githubHosts = map[string]bool{
+	"github.com":                true,
+	"api.github.com":            true,
+	"raw.githubusercontent.com": true,
+	"codeload.github.com":       true,
+}
+
+// Credentials authenticate requests to one host.
+type Credentials struct {
+	Username string // Empty for bearer tokens
+	Password string // The token, or the password from ~/.netrc
+}
+
+// Header returns the value of the Authorization header for c.
+func (c Credentials) Header() string {
+	if c.Username == "" {
+		return "Bearer " + c.Password
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password))
+}
+
+type authKey struct{}
+
+// WithAuth returns a context carrying the auth block of the config, consulted
+// by CredentialsFor before the environment and ~/.netrc.
+func WithAuth(ctx context.Context, auth []HostAuth) context.Context {
+	return context.WithValue(ctx, authKey{}, auth)
+}
+
+// CredentialsFor finds credentials for host. In order, it looks at the auth
+// block of the config (see WithAuth), GH_TOKEN or GITHUB_TOKEN for GitHub hosts,
+// and ~/.netrc. Every credential found is registered for redaction.
+func CredentialsFor(ctx context.Context, host string) (Credentials, bool) {
+	host = canonicalHost(host)
+
+	auth, _ := ctx.Value(authKey{}).([]HostAuth)
+	for _, a := range auth {
+		if canonicalHost(a.Host) != host {
+			continue
+		}
+		if token := os.Getenv(a.TokenEnv); token != "" {
+			RegisterSecret(token)
+			return Credentials{Username: a.Username, Password: token}, true
+		}
+	}
+
+	if host == "github.com" {
+		for _, env := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+			if token := os.Getenv(env); token != "" {
+				RegisterSecret(token)
+				return Credentials{Password: token}, true
+			}
+		}
+	}
+
+	if creds, ok := netrcCredentials(host); ok {
+		RegisterSecret(creds.Password)
+		return creds, true
+	}
+	return Credentials{}, false
+}
+
+// NewRequest creates an HTTP request carrying the credentials for its host, if any.
+func NewRequest(ctx context.Context, method, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, errors.New(Redact(fmt.Sprintf("invalid URL: %v", err)))
+	}
+	if creds, ok := CredentialsFor(ctx, req.URL.Hostname()); ok {
+		req.Header.Set("Authorization", creds.Header())
+	}
+	return req, nil
+}
+
+func canonicalHost(host string) string {
+	host = strings.ToLower(host)
+	if githubHosts[host] {
+		return "github.com"
+	}
+	return host
+}
+
+// netrcCredentials looks host up in $NETRC or ~/.netrc, falling back to the
+// default entry.
+func netrcCredentials(host string) (Credentials, bool) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Credentials{}, false
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Credentials{}, false
+	}
+	defer f.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(f)
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		// A macro definition runs until the next empty line.
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if field == "macdef" {
+				fields = fields[:i]
+				inMacro = true
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+
+	var found, fallback *Credentials
+	var current *Credentials
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(tokens) {
+				i++
+				if found == nil && canonicalHost(tokens[i]) == host {
+					found = &Credentials{}
+					current = found
+				}
+			}
+		case "default":
+			current = nil
+			if fallback == nil {
+				fallback = &Credentials{}
+				current = fallback
+			}
+		case "login", "password", "account":
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			if current == nil {
+				continue
+			}
+			if tokens[i-1] == "login" {
+				current.Username = tokens[i]
+			} else if tokens[i-1] == "password" {
+				current.Password = tokens[i]
+			}
+		}
+	}
+
+	for _, creds := range []*Credentials{found, fallback} {
+		if creds != nil && creds.Password != "" {
+			return *creds, true
+		}
+	}
+	return Credentials{}, false
+}
+
+var (
+	secretsMu sync.Mutex
+	secrets   = make(map[string]bool)
+
+	urlUserinfo = regexp.MustCompile(`://[^/@\s]+@`)
+	// urlQuerySecret matches the value of a query parameter that looks like a
+	// credential, e.g. ?token=, &access_token= or X-Amz-Signature=.
+	urlQuerySecret = regexp.MustCompile(`(?i)([?&][\w.-]*(?:token|key|secret|password|passwd|auth|sig|signature|credential)[\w.-]*=)[^&#\s"'<>]+`)
+)
+
+// RegisterSecret makes Redact hide s from now on.
+func RegisterSecret(s string) {
+	if len(s) < 4 {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets[s] = true
+}
+
+// Redact hides registered secrets and credentials embedded in URLs in s, as
+// userinfo or as query parameters. Use it on anything that may end up in
+// output or traces.
+func Redact(s string) string {
+	s = urlUserinfo.ReplaceAllString(s, "://****@")
+	s = urlQuerySecret.ReplaceAllString(s, "${1}****")
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "****")
+	}
+	return s
+}
+
+// RedactURL is Redact for a URL that may carry credentials as userinfo or in
+// its query.
+func RedactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.User != nil {
+		u.User = nil
+		return Redact(strings.Replace(u.String(), "://", "://****@", 1))
+	}
+	return Redact(rawURL)
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialsFor(t *testing.T) {
+	netrc := filepath.Join(t.TempDir(), "netrc")
+	require.NoError(t, os.WriteFile(netrc, []byte(`# team mirror
+machine git.example.com login deploy password netrc-secret
+macdef init
+machine ignored.example.com password nope
+
+default login anonymous password default-secret
+`), 0600))
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		auth  []HostAuth
+		host  string
+		want  Credentials
+		found bool
+	}{
+		{
+			name:  "Auth block takes precedence",
+			env:   map[string]string{"TEAM_TOKEN": "block-secret", "GH_TOKEN": "gh-secret"},
+			auth:  []HostAuth{{Host: "github.com", TokenEnv: "TEAM_TOKEN"}},
+			host:  "raw.githubusercontent.com",
+			want:  Credentials{Password: "block-secret"},
+			found: true,
+		},
+		{
+			name:  "Auth block with username",
+			env:   map[string]string{"TEAM_TOKEN": "block-secret"},
+			auth:  []HostAuth{{Host: "git.example.com", TokenEnv: "TEAM_TOKEN", Username: "ci"}},
+			host:  "git.example.com",
+			want:  Credentials{Username: "ci", Password: "block-secret"},
+			found: true,
+		},
+		{
+			name:  "Unset auth env falls through to GH_TOKEN",
+			env:   map[string]string{"GH_TOKEN": "gh-secret"},
+			auth:  []HostAuth{{Host: "github.com", TokenEnv: "TEAM_TOKEN"}},
+			host:  "github.com",
+			want:  Credentials{Password: "gh-secret"},
+			found: true,
+		},
+		{
+			name:  "GITHUB_TOKEN for GitHub hosts",
+			env:   map[string]string{"GITHUB_TOKEN": "github-secret"},
+			host:  "api.github.com",
+			want:  Credentials{Password: "github-secret"},
+			found: true,
+		},
+		{
+			name:  "GitHub token isn't sent elsewhere",
+			env:   map[string]string{"GITHUB_TOKEN": "github-secret", "NETRC": filepath.Join(t.TempDir(), "none")},
+			host:  "example.com",
+			found: false,
+		},
+		{
+			name:  "Netrc machine",
+			env:   map[string]string{"NETRC": netrc},
+			host:  "git.example.com",
+			want:  Credentials{Username: "deploy", Password: "netrc-secret"},
+			found: true,
+		},
+		{
+			name:  "Netrc macros are skipped",
+			env:   map[string]string{"NETRC": netrc},
+			host:  "ignored.example.com",
+			want:  Credentials{Username: "anonymous", Password: "default-secret"},
+			found: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"GH_TOKEN", "GITHUB_TOKEN", "TEAM_TOKEN"} {
+				t.Setenv(key, "")
+			}
+			t.Setenv("NETRC", filepath.Join(t.TempDir(), "none"))
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, found := CredentialsFor(WithAuth(context.Background(), tt.auth), tt.host)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCredentialsHeader(t *testing.T) {
+	assert.Equal(t, "Bearer abc", Credentials{Password: "abc"}.Header())
+	assert.Equal(t, "Basic dXNlcjpwYXNz", Credentials{Username: "user", Password: "pass"}.Header())
+}
+
+func TestDownloadSendsCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "none"))
+	t.Setenv("DOTFILES_TOKEN", "s3cr3t-token")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("private"))
+	}))
+	defer server.Close()
+
+	_, err := Download(context.Background(), server.URL+"/private")
+	assert.ErrorContains(t, err, "HTTP status 404")
+
+	ctx := WithAuth(context.Background(), []HostAuth{{Host: "127.0.0.1", TokenEnv: "DOTFILES_TOKEN"}})
+	content, err := Download(ctx, server.URL+"/private")
+	require.NoError(t, err)
+	assert.Equal(t, "private", string(content))
+}
+
+func TestRedact(t *testing.T) {
+	RegisterSecret("ghp_redactme")
+	RegisterSecret("abc") // Too short to redact safely
+
+	assert.Equal(t, "token **** for abc", Redact("token ghp_redactme for abc"))
+	assert.Equal(t, "clone https://****@github.com/org/repo failed", Redact("clone https://user:pw@github.com/org/repo failed"))
+	assert.Equal(t, "https://****@example.com/a", RedactURL("https://user:pw@example.com/a"))
+	assert.Equal(t, "https://example.com/a", RedactURL("https://example.com/a"))
+	assert.Equal(t, "https://example.com/a?token=****&ref=main", RedactURL("https://example.com/a?token=s3cr3t&ref=main"))
+	assert.Equal(t, "GET https://s3.example.com/x?X-Amz-Signature=**** failed", Redact("GET https://s3.example.com/x?X-Amz-Signature=ab12cd failed"))
+	assert.Equal(t, "https://****@example.com/a?access_token=****#top", RedactURL("https://u:p@example.com/a?access_token=gho_x#top"))
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Managed blocks are marker-delimited sections of a shell rc file that mycli
+// owns, one per name. Everything outside them is left untouched, so they can
+// be inserted, updated in place and removed any number of times.
+const (
+	blockBeginFormat = "# >>> mycli: %s >>>"
+	blockEndFormat   = "# <<< mycli: %s <<<"
+)
+
+// UpsertBlock returns content with the managed block name set to body. An
+// existing block is replaced in place; otherwise the block is appended.
+func UpsertBlock(content, name, body string) string {
+	block := blockText(name, body)
+	if start, end, ok := findBlock(content, name); ok {
+		return content[:start] + block + content[end:]
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return content + block
+}
+
+// UpsertBlockBefore is UpsertBlock for files where order matters. The block
+// goes in front of the first line outside managed blocks that before matches,
+// along with the comments directly above that line, and is moved there if it
+// is further down. Without such a line it is placed like UpsertBlock does.
+func UpsertBlockBefore(content, name, body string, before func(line string) bool) string {
+	at := userLine(content, before)
+	if start, _, ok := findBlock(content, name); at < 0 || (ok && start < at) {
+		return UpsertBlock(content, name, body)
+	}
+	// The block, if any, is below at, so removing it leaves at where it is.
+	content = RemoveBlock(content, name)
+	return content[:at] + blockText(name, body) + "\n" + content[at:]
+}
+
+func blockText(name, body string) string {
+	block := fmt.Sprintf(blockBeginFormat, name) + "\n"
+	if body = strings.TrimRight(body, "\n"); body != "" {
+		block += body + "\n"
+	}
+	return block + fmt.Sprintf(blockEndFormat, name) + "\n"
+}
+
+// userLine returns the offset of the first line outside managed blocks that
+// match matches, or of the comment lines directly above it, or -1.
+func userLine(content string, match func(line string) bool) int {
+	offset, comments := 0, -1
+	inBlock := ""
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case inBlock == "" && strings.HasPrefix(trimmed, "# >>> mycli: ") && strings.HasSuffix(trimmed, " >>>"):
+			inBlock = strings.TrimSuffix(strings.TrimPrefix(trimmed, "# >>> mycli: "), " >>>")
+			comments = -1
+		case inBlock != "":
+			if trimmed == fmt.Sprintf(blockEndFormat, inBlock) {
+				inBlock = ""
+			}
+		case match(trimmed):
+			if comments >= 0 {
+				return comments
+			}
+			return offset
+		case strings.HasPrefix(strings.TrimSpace(trimmed), "#"):
+			if comments < 0 {
+				comments = offset
+			}
+		default:
+			comments = -1
+		}
+		offset += len(line)
+	}
+	return -1
+}
+
+// RemoveBlock returns content without the managed block name, along with the
+// blank line UpsertBlock put in front of it.
+func RemoveBlock(content, name string) string {
+	start, end, ok := findBlock(content, name)
+	if !ok {
+		return content
+	}
+	before := content[:start]
+	if strings.HasSuffix(before, "\n\n") {
+		before = before[:len(before)-1]
+	}
+	return before + content[end:]
+}
+
+// HasBlock reports whether content contains the managed block name.
+func HasBlock(content, name string) bool {
+	_, _, ok := findBlock(content, name)
+	return ok
+}
+
+// StripBlocks returns content without any managed blocks, which is what the
+// user wrote themselves.
+func StripBlocks(content string) string {
+	var kept []string
+	inBlock := ""
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case inBlock == "" && strings.HasPrefix(trimmed, "# >>> mycli: ") && strings.HasSuffix(trimmed, " >>>"):
+			inBlock = strings.TrimSuffix(strings.TrimPrefix(trimmed, "# >>> mycli: "), " >>>")
+		case inBlock != "" && trimmed == fmt.Sprintf(blockEndFormat, inBlock):
+			inBlock = ""
+		case inBlock == "":
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// findBlock returns the byte range of the managed block name, end marker line
+// included.
+func findBlock(content, name string) (int, int, bool) {
+	begin := fmt.Sprintf(blockBeginFormat, name)
+	end := fmt.Sprintf(blockEndFormat, name)
+
+	offset := 0
+	start := -1
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case start < 0 && trimmed == begin:
+			start = offset
+		case start >= 0 && trimmed == end:
+			return start, offset + len(line), true
+		}
+		offset += len(line)
+	}
+	return 0, 0, false
+}
+
+// SetManagedBlock writes body as the managed block name in the file at path,
+// creating the file if needed. It reports whether the file changed.
+func SetManagedBlock(path, name, body string) (bool, error) {
+	return editManagedBlocks(path, func(content string) string {
+		return UpsertBlock(content, name, body)
+	})
+}
+
+// SetManagedBlockBefore is SetManagedBlock placing the block like
+// UpsertBlockBefore.
+func SetManagedBlockBefore(path, name, body string, before func(line string) bool) (bool, error) {
+	return editManagedBlocks(path, func(content string) string {
+		return UpsertBlockBefore(content, name, body, before)
+	})
+}
+
+// RemoveManagedBlock removes the managed block name from the file at path. A
+// missing file or block is not an error. It reports whether the file changed.
+func RemoveManagedBlock(path, name string) (bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+	return editManagedBlocks(path, func(content string) string {
+		return RemoveBlock(content, name)
+	})
+}
+
+func editManagedBlocks(path string, edit func(string) string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	updated := edit(string(content))
+	if updated == string(content) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	// rc files are often symlinks into a dotfiles repo; write through them
+	// instead of replacing the link.
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if err := WriteFileAtomic(path, []byte(updated), 0644); err != nil {
+		return false, fmt.Errorf("failed to update %s: %v", path, err)
+	}
+	return true, nil
+}
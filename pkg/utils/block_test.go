@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		body    string
+		want    string
+	}{
+		{
+			name: "Empty file",
+			body: `eval "$(zoxide init zsh)"`,
+			want: "# >>> mycli: zoxide >>>\neval \"$(zoxide init zsh)\"\n# <<< mycli: zoxide <<<\n",
+		},
+		{
+			name:    "Appended after existing content",
+			content: "export EDITOR=nvim",
+			body:    "alias z=zoxide",
+			want:    "export EDITOR=nvim\n\n# >>> mycli: zoxide >>>\nalias z=zoxide\n# <<< mycli: zoxide <<<\n",
+		},
+		{
+			name:    "Updated in place",
+			content: "a\n# >>> mycli: zoxide >>>\nold\n# <<< mycli: zoxide <<<\nb\n",
+			body:    "new\nlines\n",
+			want:    "a\n# >>> mycli: zoxide >>>\nnew\nlines\n# <<< mycli: zoxide <<<\nb\n",
+		},
+		{
+			name:    "Other blocks untouched",
+			content: "# >>> mycli: fzf >>>\nfzf\n# <<< mycli: fzf <<<\n",
+			body:    "zoxide",
+			want:    "# >>> mycli: fzf >>>\nfzf\n# <<< mycli: fzf <<<\n\n# >>> mycli: zoxide >>>\nzoxide\n# <<< mycli: zoxide <<<\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UpsertBlock(tt.content, "zoxide", tt.body)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, got, UpsertBlock(got, "zoxide", tt.body), "upserting twice should be a no-op")
+		})
+	}
+}
+
+func TestUpsertBlockBefore(t *testing.T) {
+	isHost := func(line string) bool { return strings.HasPrefix(line, "Host ") }
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "Inserted before the first match",
+			content: "Include extra\n\nHost *\n  User me\n",
+			want:    "Include extra\n\n# >>> mycli: gh >>>\nHost gh\n# <<< mycli: gh <<<\n\nHost *\n  User me\n",
+		},
+		{
+			name:    "Comments above the match stay with it",
+			content: "# defaults\nHost *\n",
+			want:    "# >>> mycli: gh >>>\nHost gh\n# <<< mycli: gh <<<\n\n# defaults\nHost *\n",
+		},
+		{
+			name:    "Moved up from below the match",
+			content: "Host *\n  User me\n\n# >>> mycli: gh >>>\nHost old\n# <<< mycli: gh <<<\n",
+			want:    "# >>> mycli: gh >>>\nHost gh\n# <<< mycli: gh <<<\n\nHost *\n  User me\n",
+		},
+		{
+			name:    "Lines in other blocks don't count",
+			content: "# >>> mycli: a >>>\nHost a\n# <<< mycli: a <<<\n",
+			want:    "# >>> mycli: a >>>\nHost a\n# <<< mycli: a <<<\n\n# >>> mycli: gh >>>\nHost gh\n# <<< mycli: gh <<<\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UpsertBlockBefore(tt.content, "gh", "Host gh", isHost)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, got, UpsertBlockBefore(got, "gh", "Host gh", isHost), "upserting twice should be a no-op")
+		})
+	}
+}
+
+func TestRemoveBlock(t *testing.T) {
+	original := "export EDITOR=nvim\n"
+	content := UpsertBlock(original, "zoxide", "zoxide")
+	assert.True(t, HasBlock(content, "zoxide"))
+	assert.Equal(t, original, RemoveBlock(content, "zoxide"))
+	assert.Equal(t, original, RemoveBlock(original, "zoxide"))
+
+	// An unterminated block is left alone rather than eating the rest of the file.
+	broken := "# >>> mycli: zoxide >>>\nexport PATH=x\n"
+	assert.Equal(t, broken, RemoveBlock(broken, "zoxide"))
+}
+
+func TestStripBlocks(t *testing.T) {
+	content := "mine\n# >>> mycli: a >>>\nmanaged\n# <<< mycli: a <<<\nalso mine\n"
+	assert.Equal(t, "mine\nalso mine\n", StripBlocks(content))
+}
+
+func TestSetManagedBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zshrc")
+	require.NoError(t, os.WriteFile(path, []byte("# mine\n"), 0600))
+
+	changed, err := SetManagedBlock(path, "fzf", "source ~/.fzf.zsh")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = SetManagedBlock(path, "fzf", "source ~/.fzf.zsh")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	changed, err = RemoveManagedBlock(path, "fzf")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# mine\n", string(content))
+
+	changed, err = RemoveManagedBlock(filepath.Join(t.TempDir(), "missing"), "fzf")
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestSetManagedBlockThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "zshrc")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	require.NoError(t, os.WriteFile(target, []byte("# mine\n"), 0644))
+	link := filepath.Join(dir, ".zshrc")
+	require.NoError(t, os.Symlink(target, link))
+
+	_, err := SetManagedBlock(link, "fzf", "source ~/.fzf.zsh")
+	require.NoError(t, err)
+
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link should be kept")
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "source ~/.fzf.zsh")
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+)
+
+const diffContext = 3
+
+// maxDiffCells bounds the LCS table; larger inputs are diffed as a full replacement.
+const maxDiffCells = 4_000_000
+
+type diffOp struct {
+	kind byte // ' ' for an unchanged line, '-' for a removed one, '+' for an added one
+	line string
+}
+
+// UnifiedDiff returns a unified diff turning a into b, or "" when they are equal.
+func UnifiedDiff(oldName, newName string, a, b []byte) string {
+	ops := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	// oldLine[k] and newLine[k] count the lines before ops[k] on each side.
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	changed := false
+	for k, op := range ops {
+		oldLine[k+1], newLine[k+1] = oldLine[k], newLine[k]
+		if op.kind != '+' {
+			oldLine[k+1]++
+		}
+		if op.kind != '-' {
+			newLine[k+1]++
+		}
+		if op.kind != ' ' {
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := max(i-diffContext, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			j := end
+			for j < len(ops) && ops[j].kind == ' ' {
+				j++
+			}
+			if j == len(ops) || j-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = j
+		}
+
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(oldLine[start], oldLine[end]-oldLine[start]),
+			hunkRange(newLine[start], newLine[end]-newLine[start]))
+		for _, op := range ops[start:end] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(strings.TrimSuffix(op.line, "\n"))
+			buf.WriteByte('\n')
+			if !strings.HasSuffix(op.line, "\n") {
+				buf.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+// ConflictMarkers merges a and b line by line, wrapping every changed region
+// in git-style conflict markers labelled with ours and theirs.
+func ConflictMarkers(ours, theirs string, a, b []byte) []byte {
+	ops := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	var buf strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			buf.WriteString(ops[i].line)
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind != ' '; i++ {
+			if ops[i].kind == '-' {
+				removed = append(removed, ops[i].line)
+			} else {
+				added = append(added, ops[i].line)
+			}
+		}
+		fmt.Fprintf(&buf, "<<<<<<< %s\n", ours)
+		writeLines(&buf, removed)
+		buf.WriteString("=======\n")
+		writeLines(&buf, added)
+		fmt.Fprintf(&buf, ">>>>>>> %s\n", theirs)
+	}
+	return []byte(buf.String())
+}
+
+// HasConflictMarkers reports whether content still contains a conflict marker line.
+func HasConflictMarkers(content []byte) bool {
+	for _, line := range splitLines(string(content)) {
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") || strings.TrimRight(line, "\r\n") == "=======" {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintDiff writes a unified diff to iostream.Out, colored with its ColorScheme.
+func PrintDiff(iostream *iostreams.IOStreams, diff string) {
+	cs := iostream.ColorScheme()
+	for _, line := range splitLines(diff) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			line = cs.Bold(line)
+		case strings.HasPrefix(line, "@@"):
+			line = cs.Cyan(line)
+		case strings.HasPrefix(line, "-"):
+			line = cs.Red(line)
+		case strings.HasPrefix(line, "+"):
+			line = cs.Green(line)
+		}
+		fmt.Fprintln(iostream.Out, line)
+	}
+}
+
+func writeLines(buf *strings.Builder, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits s into lines, each keeping its trailing newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes an edit script from a to b using the longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffOp{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]diffOp{{' ', a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	ops := prefix
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return append(ops, suffix...)
+	}
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		}
+	}
+	return append(ops, suffix...)
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "added to empty file",
+			a:    "",
+			b:    "x\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1 @@\n+x\n",
+		},
+		{
+			name: "missing trailing newline",
+			a:    "a\n",
+			b:    "a",
+			want: "--- old\n+++ new\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UnifiedDiff("old", "new", []byte(tt.a), []byte(tt.b)))
+		})
+	}
+}
+
+func TestConflictMarkers(t *testing.T) {
+	merged := ConflictMarkers("current", "new", []byte("a\nb\nc\n"), []byte("a\nB\nc\nd"))
+	assert.Equal(t, "a\n<<<<<<< current\nb\n=======\nB\n>>>>>>> new\nc\n<<<<<<< current\n=======\nd\n>>>>>>> new\n", string(merged))
+	assert.True(t, HasConflictMarkers(merged))
+	assert.False(t, HasConflictMarkers([]byte("a\n== heading ==\n")))
+}
+
+func TestPrintDiff(t *testing.T) {
+	ios, _, stdout, _ := iostreams.Test()
+	diff := UnifiedDiff("old", "new", []byte("a\n"), []byte("b\n"))
+	PrintDiff(ios, diff)
+	assert.Equal(t, diff, stdout.String())
+	assert.Equal(t, 5, strings.Count(stdout.String(), "\n"))
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+type offlineKey struct{}
+
+// ErrNotCached is wrapped by errors for content that is needed offline but was
+// never downloaded.
+var ErrNotCached = errors.New("not cached")
+
+// WithOffline returns a context in which Download serves content only from the
+// local cache and never touches the network.
+func WithOffline(ctx context.Context, offline bool) context.Context {
+	return context.WithValue(ctx, offlineKey{}, offline)
+}
+
+// IsOffline reports whether ctx was marked offline with WithOffline.
+func IsOffline(ctx context.Context) bool {
+	offline, _ := ctx.Value(offlineKey{}).(bool)
+	return offline
+}
+
+// CacheDir returns the directory downloaded content is cached in.
+func CacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".mycli", "cache"), nil
+}
+
+// cacheEntry describes a cached response; the body is stored next to it.
+type cacheEntry struct {
+	URL          string    `yaml:"url"`
+	ETag         string    `yaml:"etag,omitempty"`
+	LastModified string    `yaml:"last_modified,omitempty"`
+	Fetched      time.Time `yaml:"fetched"`
+}
+
+// Download fetches rawURL and returns the response body. GitHub blob URLs are
+// fetched from raw.githubusercontent.com.
+//
+// Responses are cached under ~/.mycli/cache and revalidated with ETag and
+// If-Modified-Since on later calls. Only in an offline context (see
+// WithOffline) is the cache served without asking the server, so content that
+// can't be revalidated is never used silently.
+//
+// Requests carry the credentials CredentialsFor finds for the host, and errors
+// are redacted so they never leak them.
+func Download(ctx context.Context, rawURL string) ([]byte, error) {
+	convertedURL, err := ConvertToRawGitHubURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("error converting URL: %v", err)
+	}
+
+	parsedURL, err := url.Parse(convertedURL)
+	if err != nil {
+		return nil, errors.New(Redact(fmt.Sprintf("invalid URL: %v", err)))
+	}
+	if parsedURL.Scheme == "" {
+		return nil, fmt.Errorf("URL scheme is missing. Please provide a complete URL including http:// or https://")
+	}
+
+	cachePath := ""
+	if dir, err := CacheDir(); err == nil {
+		cachePath = filepath.Join(dir, SHA256Hex([]byte(convertedURL)))
+	}
+	entry, cached := readCache(cachePath)
+
+	if IsOffline(ctx) {
+		if cached == nil {
+			return nil, fmt.Errorf("%s is %w, run once without --offline to download it", RedactURL(rawURL), ErrNotCached)
+		}
+		return cached, nil
+	}
+
+	req, err := NewRequest(ctx, http.MethodGet, convertedURL)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		msg := fmt.Sprintf("failed to download %s: %v", rawURL, err)
+		if cached != nil {
+			msg += ", run with --offline to use the cached copy"
+		}
+		return nil, errors.New(Redact(msg))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: HTTP status %d", RedactURL(rawURL), resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.New(Redact(fmt.Sprintf("failed to download %s: %v", rawURL, err)))
+	}
+	// The cache is an optimisation, so failing to write it isn't an error.
+	_ = writeCache(cachePath, cacheEntry{
+		URL:          convertedURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}, content)
+	return content, nil
+}
+
+func readCache(path string) (cacheEntry, []byte) {
+	var entry cacheEntry
+	if path == "" {
+		return entry, nil
+	}
+	meta, err := os.ReadFile(path + ".yaml")
+	if err != nil || yaml.Unmarshal(meta, &entry) != nil {
+		return entry, nil
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return entry, nil
+	}
+	return entry, body
+}
+
+// writeCache stores a response. Cached content may be private, e.g. fetched
+// with a token, so only the user can read it, whatever an older cache's
+// permissions were.
+func writeCache(path string, entry cacheEntry, body []byte) error {
+	if path == "" {
+		return nil
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+	meta, err := yaml.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := writePrivate(path, body); err != nil {
+		return err
+	}
+	return writePrivate(path+".yaml", meta)
+}
+
+// writePrivate atomically writes a file only its owner can read.
+func writePrivate(path string, data []byte) error {
+	return WriteAtomic(path, 0600, func(f *os.File) error {
+		if err := f.Chmod(0600); err != nil {
+			return err
+		}
+		_, err := f.Write(data)
+		return err
+	})
+}
+
+// SHA256Hex returns the hex-encoded SHA-256 digest of content.
+func SHA256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySHA256 checks content against the expected hex digest. An empty
+// expected digest means the content isn't pinned and always passes.
+func VerifySHA256(name string, content []byte, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	if actual := SHA256Hex(content); !strings.EqualFold(actual, strings.TrimSpace(expected)) {
+		return fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", RedactURL(name), expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	content, err := Download(context.Background(), server.URL+"/file")
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	_, err = Download(context.Background(), server.URL+"/missing")
+	assert.EqualError(t, err, "failed to download "+server.URL+"/missing: HTTP status 404")
+
+	_, err = Download(context.Background(), "not a url")
+	assert.Error(t, err)
+}
+
+func TestDownloadCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	body := "v1"
+	var requests, revalidated int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := `"` + body + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	url := server.URL + "/init.lua"
+	ctx := context.Background()
+
+	content, err := Download(ctx, url)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+
+	// Unchanged content is revalidated rather than downloaded again.
+	content, err = Download(ctx, url)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+	assert.Equal(t, 1, revalidated)
+
+	body = "v2"
+	content, err = Download(ctx, url)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(content))
+
+	// Offline, the cache is used without any request.
+	server.Close()
+	before := requests
+	content, err = Download(WithOffline(ctx, true), url)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(content))
+	assert.Equal(t, before, requests)
+
+	// Online, an unreachable server is an error rather than a silent
+	// fallback to content that may be stale.
+	_, err = Download(ctx, url)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "run with --offline to use the cached copy")
+
+	_, err = Download(WithOffline(ctx, true), server.URL+"/other")
+	assert.EqualError(t, err, server.URL+"/other is not cached, run once without --offline to download it")
+}
+
+func TestDownloadCachePermissions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir, err := CacheDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+	url := server.URL + "/npmrc"
+	path := filepath.Join(dir, SHA256Hex([]byte(url)))
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	_, err = Download(context.Background(), url)
+	require.NoError(t, err)
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	for _, file := range []string{path, path + ".yaml"} {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), file)
+	}
+}
+
+func TestWithOffline(t *testing.T) {
+	assert.False(t, IsOffline(context.Background()))
+	assert.True(t, IsOffline(WithOffline(context.Background(), true)))
+	assert.False(t, IsOffline(WithOffline(context.Background(), false)))
+}
+
+func TestVerifySHA256(t *testing.T) {
+	content := []byte("hello\n")
+	sum := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+	assert.Equal(t, sum, SHA256Hex(content))
+	assert.NoError(t, VerifySHA256("x", content, ""))
+	assert.NoError(t, VerifySHA256("x", content, sum))
+	assert.NoError(t, VerifySHA256("x", content, "5891B5B522D5DF086D0FF0B110FBD9D21BB4FC7163AF34D08286A2E846F6BE03"))
+	assert.EqualError(t, VerifySHA256("x", []byte("changed"), sum),
+		"checksum mismatch for x: expected sha256 "+sum+", got "+SHA256Hex([]byte("changed")))
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+// Shell is a login shell mycli writes rc files for: which files those are and
+// how PATH and environment changes are spelled in them.
+type Shell struct {
+	Name string // ShellBash, ShellZsh or ShellFish
+}
+
+// DetectShell returns the user's login shell from $SHELL. Shells mycli doesn't
+// know are treated as zsh.
+func DetectShell() Shell {
+	switch name := filepath.Base(os.Getenv("SHELL")); name {
+	case ShellBash, ShellFish:
+		return Shell{Name: name}
+	}
+	return Shell{Name: ShellZsh}
+}
+
+// RCFiles returns the shell's rc files relative to the home directory, the
+// one mycli edits first. bash reads .bash_profile for login shells, which is
+// every terminal on macOS, and .bashrc for the interactive shells Linux
+// terminals start.
+func (s Shell) RCFiles(goos string) []string {
+	switch s.Name {
+	case ShellFish:
+		return []string{filepath.Join(".config", "fish", "config.fish")}
+	case ShellBash:
+		if goos == "darwin" {
+			return []string{".bash_profile", ".bashrc"}
+		}
+		return []string{".bashrc", ".bash_profile", ".profile"}
+	}
+	return []string{".zshrc", ".zprofile"}
+}
+
+// RCFile returns the rc file mycli edits, relative to the home directory.
+func (s Shell) RCFile(goos string) string {
+	return s.RCFiles(goos)[0]
+}
+
+// PathLine returns the line adding dir to PATH, in front of it when prepend
+// is set.
+func (s Shell) PathLine(dir string, prepend bool) string {
+	switch {
+	case s.Name == ShellFish && prepend:
+		return fmt.Sprintf("set -gx PATH \"%s\" $PATH", dir)
+	case s.Name == ShellFish:
+		return fmt.Sprintf("set -gx PATH $PATH \"%s\"", dir)
+	case prepend:
+		return fmt.Sprintf("export PATH=\"%s:$PATH\"", dir)
+	}
+	return fmt.Sprintf("export PATH=\"$PATH:%s\"", dir)
+}
+
+// EnvLine returns the line exporting name with value. The value is double
+// quoted, so $VARIABLES in it are expanded.
+func (s Shell) EnvLine(name, value string) string {
+	if s.Name == ShellFish {
+		return fmt.Sprintf("set -gx %s \"%s\"", name, value)
+	}
+	return fmt.Sprintf("export %s=\"%s\"", name, value)
+}
+
+// EvalLine returns the line running command and evaluating its output, as
+// tools like `brew shellenv` expect.
+func (s Shell) EvalLine(command string) string {
+	if s.Name == ShellFish {
+		return command + " | source"
+	}
+	return fmt.Sprintf("eval \"$(%s)\"", command)
+}
+
+// Command returns a command running line with the shell. Lines in mycli's
+// config are written for POSIX shells, so fish, like any shell that isn't
+// installed, gets sh instead.
+func (s Shell) Command(ctx context.Context, line string) *exec.Cmd {
+	name := "sh"
+	if s.Name != ShellFish {
+		if _, err := exec.LookPath(s.Name); err == nil {
+			name = s.Name
+		}
+	}
+	return execCommandContext(ctx, name, "-c", line)
+}
+
+// ShellLines returns the lines tool keeps in its managed block of the shell's
+// rc file: its paths and env in the shell's syntax, then its shell_snippets
+// as written.
+func (s Shell) ShellLines(tool Tool) []string {
+	var lines []string
+	for _, dir := range tool.Paths {
+		lines = append(lines, s.PathLine(dir, true))
+	}
+	names := make([]string, 0, len(tool.Env))
+	for name := range tool.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, s.EnvLine(name, tool.Env[name]))
+	}
+	return append(lines, tool.ShellSnippets...)
+}
+
+// ShellRCFile returns the rc file of the user's login shell, relative to the
+// home directory.
+func ShellRCFile(goos string) string {
+	return DetectShell().RCFile(goos)
+}
+
+// DefaultShellRC returns the absolute path of ShellRCFile for this system.
+func DefaultShellRC() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(home, ShellRCFile(runtime.GOOS)), nil
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellRCFile(t *testing.T) {
+	tests := []struct {
+		goos, shell, want string
+	}{
+		{"darwin", "/bin/zsh", ".zshrc"},
+		{"darwin", "/bin/bash", ".bash_profile"},
+		{"linux", "/usr/bin/bash", ".bashrc"},
+		{"linux", "/usr/bin/fish", filepath.Join(".config", "fish", "config.fish")},
+		{"linux", "", ".zshrc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+tt.shell, func(t *testing.T) {
+			t.Setenv("SHELL", tt.shell)
+			assert.Equal(t, tt.want, ShellRCFile(tt.goos))
+		})
+	}
+}
+
+func TestShellLines(t *testing.T) {
+	tool := Tool{
+		Name:          "go",
+		Paths:         []string{"$HOME/go/bin"},
+		Env:           map[string]string{"GOPATH": "$HOME/go", "GOFLAGS": "-mod=mod"},
+		ShellSnippets: []string{"alias gt='go test ./...'"},
+	}
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{ShellZsh, []string{`export PATH="$HOME/go/bin:$PATH"`, `export GOFLAGS="-mod=mod"`, `export GOPATH="$HOME/go"`, "alias gt='go test ./...'"}},
+		{ShellBash, []string{`export PATH="$HOME/go/bin:$PATH"`, `export GOFLAGS="-mod=mod"`, `export GOPATH="$HOME/go"`, "alias gt='go test ./...'"}},
+		{ShellFish, []string{`set -gx PATH "$HOME/go/bin" $PATH`, `set -gx GOFLAGS "-mod=mod"`, `set -gx GOPATH "$HOME/go"`, "alias gt='go test ./...'"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			assert.Equal(t, tt.want, Shell{Name: tt.shell}.ShellLines(tool))
+		})
+	}
+}
+
+func TestShellLine(t *testing.T) {
+	zsh, fish := Shell{Name: ShellZsh}, Shell{Name: ShellFish}
+	assert.Equal(t, `export PATH="$PATH:/opt/bin"`, zsh.PathLine("/opt/bin", false))
+	assert.Equal(t, `set -gx PATH $PATH "/opt/bin"`, fish.PathLine("/opt/bin", false))
+	assert.Equal(t, `eval "$(brew shellenv)"`, zsh.EvalLine("brew shellenv"))
+	assert.Equal(t, "brew shellenv | source", fish.EvalLine("brew shellenv"))
+}
+
+func TestShellCommand(t *testing.T) {
+	cmd := Shell{Name: ShellFish}.Command(context.Background(), "echo hi")
+	assert.Equal(t, []string{"sh", "-c", "echo hi"}, cmd.Args)
+
+	// A shell that isn't installed falls back to sh.
+	t.Setenv("PATH", t.TempDir())
+	cmd = Shell{Name: ShellZsh}.Command(context.Background(), "echo hi")
+	assert.Equal(t, []string{"sh", "-c", "echo hi"}, cmd.Args)
+}
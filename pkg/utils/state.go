@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// State holds values mycli remembers between runs. It lives in
+// ~/.mycli/state.yaml and, unlike the tools config, is written by mycli itself.
+type State struct {
+	HomebrewPrefix string                      `yaml:"homebrew_prefix,omitempty"`
+	Configured     map[string]ConfiguredRecord `yaml:"configured,omitempty"` // Keyed by configure item name
+	Releases       map[string]string           `yaml:"releases,omitempty"`   // Installed tag of each github-release tool, keyed by tool name
+}
+
+// ConfiguredRecord remembers what `mycli configure` last installed for an item,
+// so later runs can tell local edits apart from upstream changes.
+type ConfiguredRecord struct {
+	Path      string    `yaml:"path"`
+	SHA256    string    `yaml:"sha256"` // Of the source content, or of the source tree for directories
+	Installed time.Time `yaml:"installed"`
+}
+
+// StatePath returns the location of the state file.
+func StatePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".mycli", "state.yaml"), nil
+}
+
+// LoadState reads the state file. A missing file yields an empty State.
+func LoadState() (*State, error) {
+	path, err := StatePath()
+	if err != nil {
+		return nil, err
+	}
+	state := &State{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// SaveState writes state to the state file, creating ~/.mycli if needed.
+func SaveState(state *State) error {
+	path, err := StatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, 0644)
+}
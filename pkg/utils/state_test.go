@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	state, err := LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, &State{}, state)
+
+	state.HomebrewPrefix = "/Users/me/.homebrew"
+	require.NoError(t, SaveState(state))
+
+	loaded, err := LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, "/Users/me/.homebrew", loaded.HomebrewPrefix)
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".mycli", "state.yaml"), []byte("homebrew_prefix: [oops"), 0644))
+	_, err = LoadState()
+	assert.Error(t, err)
+}
@@ -13,6 +13,7 @@ type Stats struct {
 	Duration  time.Duration
 	Status    string
 	Operation string
+	Detail    string // Optional extra information, e.g. the commit a config came from
 }
 
 type StatsCollector struct {
@@ -41,18 +42,31 @@ func (sc *StatsCollector) GetStats() []*Stats {
 func PrintCombinedStats(iostream *iostreams.IOStreams, stats []*Stats) {
 	cs := iostream.ColorScheme()
 	table := tablewriter.NewWriter(iostream.Out)
-	table.SetHeader([]string{"Name", "Duration", "Status", "Operation"})
+	header := []string{"Name", "Duration", "Status", "Operation"}
+	// Only show the Detail column when some row has one.
+	withDetail := false
+	for _, stat := range stats {
+		if stat.Detail != "" {
+			withDetail = true
+			header = append(header, "Detail")
+			break
+		}
+	}
+	table.SetHeader(header)
 	// Set table color to green
-	table.SetHeaderColor(
-		tablewriter.Colors{tablewriter.FgGreenColor},
-		tablewriter.Colors{tablewriter.FgGreenColor},
-		tablewriter.Colors{tablewriter.FgGreenColor},
-		tablewriter.Colors{tablewriter.FgGreenColor},
-	)
+	headerColors := make([]tablewriter.Colors, len(header))
+	for i := range headerColors {
+		headerColors[i] = tablewriter.Colors{tablewriter.FgGreenColor}
+	}
+	table.SetHeaderColor(headerColors...)
 
 	var totalDuration time.Duration
 	for _, stat := range stats {
-		table.Append([]string{cs.Green(stat.Name), cs.Green(stat.Duration.String()), cs.Green(stat.Status), cs.Green(stat.Operation)})
+		row := []string{cs.Green(stat.Name), cs.Green(stat.Duration.String()), cs.Green(stat.Status), cs.Green(stat.Operation)}
+		if withDetail {
+			row = append(row, cs.Green(stat.Detail))
+		}
+		table.Append(row)
 		totalDuration += stat.Duration
 	}
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/XiaoConstantine/mycli/pkg/iostreams"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +23,17 @@ func TestStatsCollector(t *testing.T) {
 	assert.Equal(t, stat1, stats[0])
 	assert.Equal(t, stat2, stats[1])
 }
+
+func TestPrintCombinedStatsDetail(t *testing.T) {
+	ios, _, stdout, _ := iostreams.Test()
+	PrintCombinedStats(ios, []*Stats{{Name: "neovim", Status: "success", Operation: "Configure"}})
+	assert.NotContains(t, stdout.String(), "DETAIL")
+
+	stdout.Reset()
+	PrintCombinedStats(ios, []*Stats{
+		{Name: "neovim", Status: "success", Operation: "Configure", Detail: "commit 1a2b3c4"},
+		{Name: "zsh", Status: "success", Operation: "Configure"},
+	})
+	assert.Contains(t, stdout.String(), "DETAIL")
+	assert.Contains(t, stdout.String(), "commit 1a2b3c4")
+}
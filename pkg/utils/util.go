@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -16,11 +17,12 @@ import (
 
 	"github.com/spf13/cobra"
 	"golang.org/x/sys/unix"
-	"gopkg.in/yaml.v2"
 )
 
 var execCommandContext = exec.CommandContext
 
+var goos = runtime.GOOS
+
 type UserUtils interface {
 	GetCurrentUser() (*user.User, error)
 	IsAdmin(ctx context.Context, u *user.User) bool
@@ -32,6 +34,8 @@ func (RealUserUtils) GetCurrentUser() (*user.User, error) {
 	return user.Current()
 }
 
+// IsAdmin reports whether u may use sudo: membership of the admin group on
+// macOS, or of the sudo, wheel or admin group on Linux.
 func (RealUserUtils) IsAdmin(ctx context.Context, u *user.User) bool {
 	cmd := execCommandContext(ctx, "groups", u.Username)
 	output, err := cmd.Output()
@@ -39,19 +43,133 @@ func (RealUserUtils) IsAdmin(ctx context.Context, u *user.User) bool {
 		fmt.Printf("Error checking groups: %v\n", err)
 		return false
 	}
-	return strings.Contains(string(output), "admin")
+
+	adminGroups := map[string]bool{"admin": true}
+	if goos == "linux" {
+		adminGroups["sudo"] = true
+		adminGroups["wheel"] = true
+	}
+	// Linux prints "user : group1 group2", macOS just "group1 group2".
+	for _, group := range strings.Fields(string(output)) {
+		if adminGroups[group] {
+			return true
+		}
+	}
+	return false
 }
 
 type ToolConfig struct {
-	Tools     []Tool          `yaml:"tools"`
-	Configure []ConfigureItem `yaml:"configure"`
+	Sources   Sources           `yaml:"sources,omitempty"`
+	Tools     []Tool            `yaml:"tools"`
+	Configure []ConfigureItem   `yaml:"configure"`
+	Variables map[string]string `yaml:"variables,omitempty"` // Values available to templated configure items as .Vars
+	Backups   BackupPolicy      `yaml:"backups,omitempty"`
+	Auth      []HostAuth        `yaml:"auth,omitempty"`
+	// SecretProviders are commands resolving {{ secret "<name>:<arg>" }} in
+	// templates, run with sh and the argument as $1.
+	SecretProviders map[string]string `yaml:"secret_providers,omitempty"`
+	Git             *GitConfig        `yaml:"git,omitempty"`
+	SSH             *SSHConfig        `yaml:"ssh,omitempty"`
+}
+
+// GitConfig is the global git configuration configure applies with
+// git config --global.
+type GitConfig struct {
+	Name          string            `yaml:"name,omitempty"`           // user.name; prompted for when set neither here nor in git
+	Email         string            `yaml:"email,omitempty"`          // user.email; prompted for like name
+	SigningKey    string            `yaml:"signing_key,omitempty"`    // user.signingkey; commits and tags are signed when set
+	SigningFormat string            `yaml:"signing_format,omitempty"` // gpg.format: openpgp, ssh or x509
+	DefaultBranch string            `yaml:"default_branch,omitempty"` // init.defaultBranch
+	Aliases       map[string]string `yaml:"aliases,omitempty"`        // alias.<name>
+	IncludeIf     []GitInclude      `yaml:"include_if,omitempty"`
+}
+
+// SSHConfig lists the SSH keys configure generates when they are missing and
+// the host entries it keeps in ~/.ssh/config.
+type SSHConfig struct {
+	Keys  []SSHKey  `yaml:"keys,omitempty"`
+	Hosts []SSHHost `yaml:"hosts,omitempty"`
+}
+
+// SSHKey is an ed25519 key pair under ~/.ssh.
+type SSHKey struct {
+	Name    string `yaml:"name"`              // File name, e.g. id_ed25519
+	Comment string `yaml:"comment,omitempty"` // Defaults to user@hostname
+}
+
+// SSHHost is a Host entry of ~/.ssh/config.
+type SSHHost struct {
+	Host         string            `yaml:"host"`
+	HostName     string            `yaml:"hostname,omitempty"`
+	User         string            `yaml:"user,omitempty"`
+	Port         int               `yaml:"port,omitempty"`
+	IdentityFile string            `yaml:"identity_file,omitempty"`
+	Options      map[string]string `yaml:"options,omitempty"` // Any other ssh_config keywords, e.g. AddKeysToAgent: "yes"
+}
+
+// GitInclude includes another git config file in repositories under Dir.
+type GitInclude struct {
+	Dir  string `yaml:"dir"`
+	Path string `yaml:"path"`
+}
+
+// HostAuth supplies credentials for downloads from one host. The token itself
+// never appears in the config, only the name of the environment variable
+// holding it.
+type HostAuth struct {
+	Host     string `yaml:"host"`
+	TokenEnv string `yaml:"token_env"`
+	Username string `yaml:"username,omitempty"` // Send the token with basic auth as this user instead of as a bearer token
+}
+
+// BackupPolicy limits how many backups of overwritten configs are kept per
+// configure item. Zero values fall back to the defaults (keep 10, no age limit).
+type BackupPolicy struct {
+	Keep   int    `yaml:"keep,omitempty"`    // Number of backups to keep
+	MaxAge string `yaml:"max_age,omitempty"` // Remove backups older than this, e.g. "720h" or "30d"
+}
+
+// Sources declares package sources (brew taps, apt and dnf repositories) that
+// must be in place before any tool is installed.
+type Sources struct {
+	Taps []Tap       `yaml:"taps,omitempty"`
+	Apt  []AptSource `yaml:"apt,omitempty"`
+	Dnf  []DnfSource `yaml:"dnf,omitempty"`
+}
+
+type Tap struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url,omitempty"` // Optional, for taps that don't follow the homebrew-<name> GitHub convention
+}
+
+type AptSource struct {
+	Name       string   `yaml:"name"`
+	URI        string   `yaml:"uri"`
+	Suite      string   `yaml:"suite,omitempty"` // Optional, defaults to the release codename of the running distribution
+	Components []string `yaml:"components,omitempty"`
+	KeyURL     string   `yaml:"key_url,omitempty"`
+}
+
+type DnfSource struct {
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"baseurl"`
+	KeyURL  string `yaml:"key_url,omitempty"`
 }
 
 type Tool struct {
-	Name           string   `yaml:"name"`
-	Method         string   `yaml:"method,omitempty"` // Optional, for specifying 'cask' or other Homebrew methods
-	InstallCommand string   `yaml:"install_command,omitempty"`
-	PostInstall    []string `yaml:"post_install,omitempty"`
+	Name           string            `yaml:"name"`
+	Method         string            `yaml:"method,omitempty"` // Optional: brew (default), cask, npm, pipx, cargo or github-release
+	InstallCommand string            `yaml:"install_command,omitempty"`
+	PostInstall    []string          `yaml:"post_install,omitempty"`
+	Version        string            `yaml:"version,omitempty"`        // Optional, pins the tool to this version so upgrade leaves it alone
+	Repo           string            `yaml:"repo,omitempty"`           // owner/repo on GitHub whose releases a github-release tool comes from
+	Asset          string            `yaml:"asset,omitempty"`          // Optional, glob matching the release asset; defaults to the one for this OS and architecture
+	Binary         string            `yaml:"binary,omitempty"`         // Optional, executable in the release asset; defaults to name
+	ScriptURL      string            `yaml:"script_url,omitempty"`     // Optional, install script that is downloaded and run with sh
+	ScriptSHA256   string            `yaml:"script_sha256,omitempty"`  // Optional, expected SHA-256 of the script at script_url
+	ShellSnippets  []string          `yaml:"shell_snippets,omitempty"` // Optional, lines kept in a managed block of the shell rc file
+	Env            map[string]string `yaml:"env,omitempty"`            // Optional, environment variables exported from the shell rc file
+	Paths          []string          `yaml:"paths,omitempty"`          // Optional, directories put in front of PATH in the shell rc file
 }
 
 type ConfigureItem struct {
@@ -59,19 +177,31 @@ type ConfigureItem struct {
 	ConfigURL        string   `yaml:"config_url,omitempty"`
 	InstallPath      string   `yaml:"install_path"`
 	ConfigureCommand []string `yaml:"configure_command,omitempty"`
+	Template         bool     `yaml:"template,omitempty"`     // Render the downloaded content with text/template before saving
+	SourcePath       string   `yaml:"source_path,omitempty"`  // Local file or directory, e.g. in a dotfiles checkout
+	Mode             string   `yaml:"mode,omitempty"`         // How source_path is installed: "copy" (default) or "symlink"
+	Repo             string   `yaml:"repo,omitempty"`         // Git repository to take the config from, cached under ~/.mycli/repos
+	Ref              string   `yaml:"ref,omitempty"`          // Branch, tag or commit of repo; defaults to the remote's default branch
+	RepoPath         string   `yaml:"repo_path,omitempty"`    // File or directory within repo; defaults to the whole repo
+	SHA256           string   `yaml:"sha256,omitempty"`       // Expected SHA-256 of the content at config_url or archive
+	Archive          string   `yaml:"archive,omitempty"`      // Tarball, zip or GitHub tree URL extracted into install_path
+	ArchivePath      string   `yaml:"archive_path,omitempty"` // Directory within archive to install; defaults to the whole archive
+	Prune            bool     `yaml:"prune,omitempty"`        // Remove files from an installed directory that are no longer in its source
+	FileMode         string   `yaml:"file_mode,omitempty"`    // Octal mode for the installed files, e.g. "0600"
+	DirMode          string   `yaml:"dir_mode,omitempty"`     // Octal mode for the directory holding install_path, or the installed directories
+	Owner            string   `yaml:"owner,omitempty"`        // "user" or "user:group" to own the installed files
+	Strategy         string   `yaml:"strategy,omitempty"`     // "replace" (default) or "merge" into the installed file
+	Format           string   `yaml:"format,omitempty"`       // json, yaml, toml or ini for merge; defaults to install_path's extension
 }
 
-// LoadToolsConfig loads tool configuration from a YAML file.
+// LoadToolsConfig loads tool configuration from a YAML file, validating it
+// with ParseToolsConfig.
 func LoadToolsConfig(filename string) (*ToolConfig, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	var config ToolConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, err
-	}
-	return &config, nil
+	return ParseToolsConfig(filename, data)
 }
 
 // GetConfigureItem retrieves a specific configuration item by name.
@@ -113,6 +243,9 @@ func ConvertToRawGitHubURL(inputURL string) (string, error) {
 	if parsedURL.Host != "github.com" {
 		return inputURL, nil // Not a GitHub URL, return as-is
 	}
+	if parts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/"); len(parts) > 3 && parts[2] == "releases" {
+		return inputURL, nil // Release assets are served from github.com itself
+	}
 
 	// Preserve trailing slash
 	hasTrailingSlash := strings.HasSuffix(parsedURL.Path, "/")
@@ -111,25 +111,50 @@ func TestRealUserUtils_IsAdmin(t *testing.T) {
 	oldExecCommandContext := execCommandContext
 	defer func() { execCommandContext = oldExecCommandContext }()
 
+	oldGoos := goos
+	defer func() { goos = oldGoos }()
+
 	tests := []struct {
 		name       string
+		goos       string
 		mockOutput string
 		want       bool
 	}{
 		{
 			name:       "User is admin",
+			goos:       "darwin",
 			mockOutput: "username admin wheel",
 			want:       true,
 		},
 		{
 			name:       "User is not admin",
+			goos:       "darwin",
 			mockOutput: "username wheel",
 			want:       false,
 		},
+		{
+			name:       "Linux sudo group",
+			goos:       "linux",
+			mockOutput: "username : username sudo docker",
+			want:       true,
+		},
+		{
+			name:       "Linux wheel group",
+			goos:       "linux",
+			mockOutput: "username : username wheel",
+			want:       true,
+		},
+		{
+			name:       "Linux group name containing admin",
+			goos:       "linux",
+			mockOutput: "username : username lpadmin",
+			want:       false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			goos = tt.goos
 			execCommandContext = func(ctx context.Context, command string, args ...string) *exec.Cmd {
 				return exec.Command("echo", tt.mockOutput)
 			}
@@ -216,6 +241,12 @@ func TestConvertToRawGitHubURL(t *testing.T) {
 			expected: "https://raw.githubusercontent.com/username/repo/main/dir/",
 			wantErr:  false,
 		},
+		{
+			name:     "GitHub release asset",
+			input:    "https://github.com/username/repo/releases/download/v1.0.0/tool_darwin_arm64.tar.gz",
+			expected: "https://github.com/username/repo/releases/download/v1.0.0/tool_darwin_arm64.tar.gz",
+			wantErr:  false,
+		},
 		{
 			name:     "Non-GitHub URL",
 			input:    "https://gitlab.com/username/repo/blob/main/file.txt",
@@ -0,0 +1,478 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError is a problem found in a config file, positioned at the line and
+// column of the key or value it concerns.
+type ConfigError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// ConfigErrors are all the problems found in a config file, in file order.
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Values accepted by the enumerated fields of the config.
+var (
+	toolMethods    = []string{"brew", "cask", "npm", "pipx", "cargo", "github-release"}
+	configModes    = []string{"copy", "symlink"}
+	configStrategy = []string{"replace", "merge"}
+	configFormats  = []string{"json", "yaml", "toml", "ini"}
+	signingFormats = []string{"openpgp", "ssh", "x509"}
+)
+
+// tapName matches user/repo, the form of Homebrew tap names and GitHub
+// repositories.
+var tapName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*/[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// repoName matches the name of an apt or dnf repository, which becomes a file
+// name under /etc.
+var repoName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// configSources are the fields a configure item can take its config from.
+var configSources = []string{"config_url", "source_path", "repo", "archive", "configure_command"}
+
+// ParseToolsConfig strictly decodes the config in data, read from filename.
+// Unknown fields, values of the wrong type and semantic problems such as
+// missing required fields, unknown methods, duplicate names or two configure
+// items sharing an install_path are all reported at once as ConfigErrors.
+func ParseToolsConfig(filename string, data []byte) (*ToolConfig, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	var config ToolConfig
+	if len(doc.Content) == 0 {
+		return &config, nil
+	}
+	root := doc.Content[0]
+
+	v := &configValidator{file: filename}
+	v.checkFields(root, reflect.TypeOf(config), "")
+	if len(v.errs) == 0 {
+		v.checkTools(mappingValue(root, "tools"))
+		v.checkConfigure(mappingValue(root, "configure"))
+		v.checkSections(root)
+	}
+	if len(v.errs) > 0 {
+		sort.SliceStable(v.errs, func(i, j int) bool {
+			if v.errs[i].Line != v.errs[j].Line {
+				return v.errs[i].Line < v.errs[j].Line
+			}
+			return v.errs[i].Column < v.errs[j].Column
+		})
+		return nil, v.errs
+	}
+	if err := root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return &config, nil
+}
+
+type configValidator struct {
+	file string
+	errs ConfigErrors
+}
+
+func (v *configValidator) addf(n *yaml.Node, format string, args ...interface{}) {
+	v.errs = append(v.errs, ConfigError{File: v.file, Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, args...)})
+}
+
+// checkFields walks n alongside the type it decodes into, reporting keys that
+// match no field and values that can't be decoded into theirs.
+func (v *configValidator) checkFields(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			v.addf(n, "%s must be a mapping", describe(path))
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Tag == "!!merge" {
+				v.checkFields(value, t, path)
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown field %q", key.Value)
+				if path != "" {
+					msg += " in " + path
+				}
+				if guess := closestField(key.Value, fields); guess != "" {
+					msg += fmt.Sprintf(", did you mean %q?", guess)
+				}
+				v.addf(key, "%s", msg)
+				continue
+			}
+			v.checkFields(value, field, joinPath(path, key.Value))
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			v.addf(n, "%s must be a list", describe(path))
+			return
+		}
+		for i, item := range n.Content {
+			v.checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			v.addf(n, "%s must be a mapping", describe(path))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v.checkFields(n.Content[i+1], t.Elem(), joinPath(path, n.Content[i].Value))
+		}
+	default:
+		if n.Kind != yaml.ScalarNode || n.Decode(reflect.New(t).Interface()) != nil {
+			v.addf(n, "%s must be %s", describe(path), kindName(t))
+		}
+	}
+}
+
+func (v *configValidator) checkTools(tools *yaml.Node) {
+	seen := make(map[string]*yaml.Node)
+	for i, tool := range sequenceItems(tools) {
+		path := fmt.Sprintf("tools[%d]", i)
+		v.checkName(tool, path, "tool", seen)
+		v.checkOneOf(tool, path, "method", toolMethods)
+		if set := setFields(tool, "install_command", "script_url"); len(set) > 1 {
+			v.addf(tool, "%s: set only one of install_command and script_url", path)
+		}
+		method := scalarValue(tool, "method")
+		custom := setFields(tool, "install_command", "script_url")
+		if method != "" && method != "brew" && method != "cask" && len(custom) > 0 {
+			v.addf(mappingKey(tool, custom[0]), "%s: %s can't be used with method %s", path, custom[0], method)
+		}
+		if method == "github-release" {
+			v.checkRelease(tool, path)
+		} else if set := setFields(tool, "repo", "asset", "binary"); len(set) > 0 {
+			v.addf(mappingKey(tool, set[0]), "%s: %s can only be used with method github-release", path, set[0])
+		}
+		// Homebrew always installs the current version of a formula or cask.
+		if (method == "" || method == "brew" || method == "cask") && len(custom) == 0 && scalarValue(tool, "version") != "" {
+			v.addf(mappingKey(tool, "version"), "%s: version can't be used with Homebrew, which only installs the latest version; name a versioned formula such as node@20 instead", path)
+		}
+	}
+}
+
+// checkRelease checks the fields of a tool installed from GitHub releases.
+func (v *configValidator) checkRelease(tool *yaml.Node, path string) {
+	v.require(tool, path, "repo")
+	if repo := scalarValue(tool, "repo"); repo != "" && !tapName.MatchString(repo) {
+		v.addf(mappingValue(tool, "repo"), "%s: invalid repo %q, expected owner/repo", path, repo)
+	}
+	if asset := scalarValue(tool, "asset"); asset != "" {
+		if _, err := filepath.Match(asset, ""); err != nil {
+			v.addf(mappingValue(tool, "asset"), "%s: invalid asset pattern %q", path, asset)
+		}
+	}
+}
+
+func (v *configValidator) checkConfigure(items *yaml.Node) {
+	seen := make(map[string]*yaml.Node)
+	paths := make(map[string]*yaml.Node)
+	for i, item := range sequenceItems(items) {
+		path := fmt.Sprintf("configure[%d]", i)
+		v.checkName(item, path, "configure item", seen)
+		v.require(item, path, "install_path")
+		v.checkOneOf(item, path, "mode", configModes)
+		v.checkOneOf(item, path, "strategy", configStrategy)
+		v.checkOneOf(item, path, "format", configFormats)
+
+		switch set := setFields(item, configSources...); len(set) {
+		case 0:
+			v.addf(item, "%s: needs one of %s", path, orList(configSources))
+		case 1:
+		default:
+			v.addf(mappingKey(item, set[1]), "%s: set only one of %s, found %s", path, orList(configSources), strings.Join(set, " and "))
+		}
+
+		if installPath := scalarValue(item, "install_path"); installPath != "" {
+			key := normalizeInstallPath(installPath)
+			if first, ok := paths[key]; ok {
+				v.addf(mappingValue(item, "install_path"), "%s: install_path %s is also used by %q on line %d", path, installPath, scalarValue(first, "name"), first.Line)
+			} else {
+				paths[key] = item
+			}
+		}
+	}
+}
+
+// checkSections checks the required fields of the smaller sections.
+func (v *configValidator) checkSections(root *yaml.Node) {
+	sources := mappingValue(root, "sources")
+	for i, tap := range sequenceItems(mappingValue(sources, "taps")) {
+		path := fmt.Sprintf("sources.taps[%d]", i)
+		v.require(tap, path, "name")
+		if name := scalarValue(tap, "name"); name != "" && !tapName.MatchString(name) {
+			v.addf(mappingValue(tap, "name"), "%s: invalid tap name %q, expected user/repo", path, name)
+		}
+	}
+	v.checkRepos(mappingValue(sources, "apt"), "sources.apt", "uri")
+	v.checkRepos(mappingValue(sources, "dnf"), "sources.dnf", "baseurl")
+	for i, auth := range sequenceItems(mappingValue(root, "auth")) {
+		v.require(auth, fmt.Sprintf("auth[%d]", i), "host", "token_env")
+	}
+	git := mappingValue(root, "git")
+	v.checkOneOf(git, "git", "signing_format", signingFormats)
+	for i, include := range sequenceItems(mappingValue(git, "include_if")) {
+		v.require(include, fmt.Sprintf("git.include_if[%d]", i), "dir", "path")
+	}
+	ssh := mappingValue(root, "ssh")
+	keys := make(map[string]*yaml.Node)
+	for i, key := range sequenceItems(mappingValue(ssh, "keys")) {
+		v.checkName(key, fmt.Sprintf("ssh.keys[%d]", i), "ssh key", keys)
+	}
+	for i, host := range sequenceItems(mappingValue(ssh, "hosts")) {
+		v.require(host, fmt.Sprintf("ssh.hosts[%d]", i), "host")
+	}
+}
+
+// checkRepos checks apt or dnf repositories: each needs a name usable as a
+// file name and the URL field urlKey.
+func (v *configValidator) checkRepos(repos *yaml.Node, section, urlKey string) {
+	seen := make(map[string]*yaml.Node)
+	for i, repo := range sequenceItems(repos) {
+		path := fmt.Sprintf("%s[%d]", section, i)
+		v.checkName(repo, path, "repository", seen)
+		v.require(repo, path, urlKey)
+		if name := scalarValue(repo, "name"); name != "" && !repoName.MatchString(name) {
+			v.addf(mappingValue(repo, "name"), "%s: invalid repository name %q, use only letters, digits, '.', '_' and '-'", path, name)
+		}
+	}
+}
+
+// checkName requires item to have a name not used by an earlier item in seen.
+func (v *configValidator) checkName(item *yaml.Node, path, kind string, seen map[string]*yaml.Node) {
+	name := scalarValue(item, "name")
+	if name == "" {
+		v.addf(item, "%s: name is required", path)
+		return
+	}
+	if first, ok := seen[name]; ok {
+		v.addf(mappingValue(item, "name"), "%s: duplicate %s %q, first defined on line %d", path, kind, name, first.Line)
+		return
+	}
+	seen[name] = item
+}
+
+func (v *configValidator) require(item *yaml.Node, path string, keys ...string) {
+	for _, key := range keys {
+		if scalarValue(item, key) == "" {
+			v.addf(item, "%s: %s is required", path, key)
+		}
+	}
+}
+
+// checkOneOf reports a value of key that isn't one of allowed. Leaving the
+// key out is always fine.
+func (v *configValidator) checkOneOf(item *yaml.Node, path, key string, allowed []string) {
+	value := scalarValue(item, key)
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.addf(mappingValue(item, key), "%s: unknown %s %q, expected %s", path, key, value, orList(allowed))
+}
+
+// mappingKey returns the key node of key in the mapping n, or n itself when
+// it has no such key so that errors still point somewhere useful.
+func mappingKey(n *yaml.Node, key string) *yaml.Node {
+	if n != nil && n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				return n.Content[i]
+			}
+		}
+	}
+	return n
+}
+
+// mappingValue returns the value of key in the mapping n, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			value := n.Content[i+1]
+			if value.Kind == yaml.AliasNode {
+				value = value.Alias
+			}
+			return value
+		}
+	}
+	return nil
+}
+
+// scalarValue returns the scalar value of key in the mapping n, or "".
+func scalarValue(n *yaml.Node, key string) string {
+	value := mappingValue(n, key)
+	if value == nil || value.Kind != yaml.ScalarNode || value.Tag == "!!null" {
+		return ""
+	}
+	return value.Value
+}
+
+// setFields returns those of keys that have a non-empty value in n.
+func setFields(n *yaml.Node, keys ...string) []string {
+	var set []string
+	for _, key := range keys {
+		value := mappingValue(n, key)
+		if value == nil || value.Tag == "!!null" || (value.Kind == yaml.ScalarNode && value.Value == "") || (value.Kind == yaml.SequenceNode && len(value.Content) == 0) {
+			continue
+		}
+		set = append(set, key)
+	}
+	return set
+}
+
+func sequenceItems(n *yaml.Node) []*yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	items := make([]*yaml.Node, len(n.Content))
+	for i, item := range n.Content {
+		if item.Kind == yaml.AliasNode {
+			item = item.Alias
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// yamlFields maps the yaml keys of struct type t to their field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// closestField suggests the field a misspelled key was probably meant to be.
+func closestField(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a number"
+	}
+	return "a string"
+}
+
+func describe(path string) string {
+	if path == "" {
+		return "the config"
+	}
+	return path
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// orList formats values as "a, b or c".
+func orList(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
+
+// normalizeInstallPath makes install paths that point at the same file
+// compare equal.
+func normalizeInstallPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if path == "~" || strings.HasPrefix(path, "~/") {
+			path = home + path[1:]
+		}
+		path = strings.NewReplacer("$HOME", home, "${HOME}", home).Replace(path)
+	}
+	return filepath.Clean(path)
+}
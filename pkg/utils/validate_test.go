@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseToolsConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		name   string
+		config string
+		errs   []string
+	}{
+		{
+			name: "valid",
+			config: `tools:
+  - name: neovim
+  - name: alacritty
+    method: cask
+configure:
+  - name: nvim
+    config_url: https://example.com/init.lua
+    install_path: ~/.config/nvim/init.lua
+    mode: copy
+  - name: zsh
+    configure_command: ["echo hi"]
+    install_path: ~/.zshrc
+`,
+		},
+		{
+			name:   "empty",
+			config: "",
+		},
+		{
+			name: "unknown fields",
+			config: `tools:
+  - name: gh
+    post_instal:
+      - gh auth login
+    methd: cask
+toolz: []
+`,
+			errs: []string{
+				`test.yaml:3:5: unknown field "post_instal" in tools[0], did you mean "post_install"?`,
+				`test.yaml:5:5: unknown field "methd" in tools[0], did you mean "method"?`,
+				`test.yaml:6:1: unknown field "toolz", did you mean "tools"?`,
+			},
+		},
+		{
+			name: "wrong types",
+			config: `tools:
+  - name: gh
+    post_install: gh auth login
+configure:
+  - name: nvim
+    config_url: https://example.com/init.lua
+    install_path: ~/.config/nvim/init.lua
+    template: maybe
+ssh:
+  hosts:
+    - host: github.com
+      port: [22]
+`,
+			errs: []string{
+				"test.yaml:3:19: tools[0].post_install must be a list",
+				"test.yaml:8:15: configure[0].template must be true or false",
+				"test.yaml:12:13: ssh.hosts[0].port must be a number",
+			},
+		},
+		{
+			name: "semantic problems",
+			config: `tools:
+  - method: formula
+  - name: gh
+  - name: gh
+    install_command: "curl x | sh"
+    script_url: https://example.com/gh.sh
+  - name: black
+    method: pipx
+    install_command: pip install black
+configure:
+  - name: nvim
+    install_path: ~/.config/nvim
+  - name: zsh
+    config_url: https://example.com/zshrc
+    source_path: ~/dotfiles/zshrc
+    install_path: ~/.zshrc
+    mode: hardlink
+  - name: zsh2
+    source_path: ~/dotfiles/zshrc
+    install_path: $HOME/.zshrc
+    strategy: overwrite
+  - name: starship
+    config_url: https://example.com/starship.toml
+git:
+  signing_format: gpg
+`,
+			errs: []string{
+				`test.yaml:2:5: tools[0]: name is required`,
+				`test.yaml:2:13: tools[0]: unknown method "formula", expected brew, cask, npm, pipx, cargo or github-release`,
+				`test.yaml:4:5: tools[2]: set only one of install_command and script_url`,
+				`test.yaml:4:11: tools[2]: duplicate tool "gh", first defined on line 3`,
+				`test.yaml:9:5: tools[3]: install_command can't be used with method pipx`,
+				`test.yaml:11:5: configure[0]: needs one of config_url, source_path, repo, archive or configure_command`,
+				`test.yaml:15:5: configure[1]: set only one of config_url, source_path, repo, archive or configure_command, found config_url and source_path`,
+				`test.yaml:17:11: configure[1]: unknown mode "hardlink", expected copy or symlink`,
+				`test.yaml:20:19: configure[2]: install_path $HOME/.zshrc is also used by "zsh" on line 13`,
+				`test.yaml:21:15: configure[2]: unknown strategy "overwrite", expected replace or merge`,
+				`test.yaml:22:5: configure[3]: install_path is required`,
+				`test.yaml:25:19: git: unknown signing_format "gpg", expected openpgp, ssh or x509`,
+			},
+		},
+		{
+			name: "versions and releases",
+			config: `tools:
+  - name: node
+    version: "20"
+  - name: uv
+    script_url: https://astral.sh/uv/install.sh
+    version: "0.4.0"
+  - name: rg
+    method: github-release
+    asset: "[x"
+  - name: fzf
+    repo: junegunn/fzf
+  - name: jq
+    method: github-release
+    repo: jq
+  - name: gh
+    method: github-release
+    repo: cli/cli
+    version: v2.60.0
+`,
+			errs: []string{
+				`test.yaml:3:5: tools[0]: version can't be used with Homebrew, which only installs the latest version; name a versioned formula such as node@20 instead`,
+				`test.yaml:7:5: tools[2]: repo is required`,
+				`test.yaml:9:12: tools[2]: invalid asset pattern "[x"`,
+				`test.yaml:11:5: tools[3]: repo can only be used with method github-release`,
+				`test.yaml:14:11: tools[4]: invalid repo "jq", expected owner/repo`,
+			},
+		},
+		{
+			name: "tap names",
+			config: `sources:
+  taps:
+    - name: hashicorp/tap
+    - name: "x; rm -rf ~"
+    - name: homebrew/cask-fonts/extra
+`,
+			errs: []string{
+				`test.yaml:4:13: sources.taps[1]: invalid tap name "x; rm -rf ~", expected user/repo`,
+				`test.yaml:5:13: sources.taps[2]: invalid tap name "homebrew/cask-fonts/extra", expected user/repo`,
+			},
+		},
+		{
+			name: "repository names",
+			config: `sources:
+  apt:
+    - name: hashicorp
+      uri: https://apt.releases.hashicorp.com
+    - name: ../../cron.d/evil
+      uri: https://example.com
+    - uri: https://example.com
+  dnf:
+    - name: "docker\n[evil]"
+      baseurl: https://download.docker.com/linux/fedora
+    - name: hashicorp
+`,
+			errs: []string{
+				`test.yaml:5:13: sources.apt[1]: invalid repository name "../../cron.d/evil", use only letters, digits, '.', '_' and '-'`,
+				`test.yaml:7:7: sources.apt[2]: name is required`,
+				`test.yaml:9:13: sources.dnf[0]: invalid repository name "docker\n[evil]", use only letters, digits, '.', '_' and '-'`,
+				`test.yaml:11:7: sources.dnf[1]: baseurl is required`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseToolsConfig("test.yaml", []byte(tt.config))
+			if len(tt.errs) == 0 {
+				require.NoError(t, err)
+				assert.NotNil(t, config)
+				return
+			}
+			var problems ConfigErrors
+			require.True(t, errors.As(err, &problems), "got %v", err)
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.Error())
+			}
+			assert.Equal(t, tt.errs, got)
+			assert.Nil(t, config)
+		})
+	}
+}
+
+func TestParseToolsConfigSyntaxError(t *testing.T) {
+	_, err := ParseToolsConfig("test.yaml", []byte("tools:\n  - name: [gh\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test.yaml: yaml: line")
+}
+
+func TestParseToolsConfigTemplate(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "config_template.yaml"))
+	require.NoError(t, err)
+	config, err := ParseToolsConfig("config_template.yaml", data)
+	require.NoError(t, err)
+	assert.NotEmpty(t, config.Tools)
+	assert.NotEmpty(t, config.Configure)
+}